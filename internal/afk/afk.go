@@ -0,0 +1,442 @@
+package afk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Satr10/wa-userbot/internal/config"
+)
+
+// Settings adalah konfigurasi AFK yang bisa diubah saat runtime lewat perintah owner.
+type Settings struct {
+	Timezone string   `json:"timezone"`
+	Schedule Schedule `json:"schedule"`
+	// Message adalah template balasan; lihat Vars untuk placeholder yang tersedia.
+	Message string `json:"message"`
+	// ManualMessage adalah template balasan saat AFK manual (.afk <alasan>).
+	ManualMessage string `json:"manualMessage"`
+	// CalendarMessage adalah template balasan saat ada event kalender yang sedang berlangsung.
+	CalendarMessage string `json:"calendarMessage"`
+	// RenotifyInterval adalah jeda sebelum chat yang sama dibalas lagi dalam satu periode AFK,
+	// dalam format time.ParseDuration. "0" berarti hanya sekali per periode.
+	RenotifyInterval string `json:"renotifyInterval"`
+}
+
+// Manual adalah AFK yang dinyalakan langsung oleh owner, terlepas dari jadwal.
+type Manual struct {
+	Since  time.Time `json:"since"`
+	Reason string    `json:"reason"`
+}
+
+// MissedMessage adalah pesan DM atau mention yang masuk selama AFK manual,
+// dikumpulkan untuk rangkuman saat owner kembali.
+type MissedMessage struct {
+	ChatID     string    `json:"chatId"`
+	SenderID   string    `json:"senderId"`
+	SenderName string    `json:"senderName"`
+	MessageID  string    `json:"messageId"`
+	Text       string    `json:"text"`
+	Time       time.Time `json:"time"`
+	Mention    bool      `json:"mention"`
+}
+
+// maxMissed membatasi jumlah pesan yang disimpan untuk rangkuman; pesan terlama dibuang.
+const maxMissed = 200
+
+// missedSaveInterval adalah jeda minimal antar penyimpanan pesan terlewat, agar grup yang ramai
+// tidak memicu penulisan store untuk setiap pesan.
+const missedSaveInterval = 30 * time.Second
+
+// errUnchanged dikembalikan fungsi perubahan di update jika state tidak berubah sehingga
+// tidak perlu disimpan.
+var errUnchanged = errors.New("state tidak berubah")
+
+// Notice mencatat kapan sebuah chat terakhir menerima balasan AFK.
+type Notice struct {
+	// Period menandai periode AFK saat balasan dikirim: waktu mulai AFK manual,
+	// atau waktu berakhir jadwal AFK.
+	Period time.Time `json:"period"`
+	At     time.Time `json:"at"`
+}
+
+// State adalah seluruh data AFK yang disimpan oleh Store.
+type State struct {
+	Settings Settings        `json:"settings"`
+	Manual   *Manual         `json:"manual,omitempty"`
+	Missed   []MissedMessage `json:"missed,omitempty"`
+	// Notified berisi balasan AFK terakhir per chat, agar satu chat tidak dibalas berulang kali.
+	Notified map[string]Notice `json:"notified,omitempty"`
+}
+
+// clone membuat salinan dalam (deep copy) dari State.
+func (s *State) clone() State {
+	c := *s
+	c.Settings.Schedule = slices.Clone(s.Settings.Schedule)
+	if s.Manual != nil {
+		manual := *s.Manual
+		c.Manual = &manual
+	}
+	c.Missed = slices.Clone(s.Missed)
+	c.Notified = maps.Clone(s.Notified)
+	return c
+}
+
+// Status adalah hasil pengecekan AFK pada satu waktu.
+type Status struct {
+	Active bool
+	// Manual berisi alasan dan waktu mulai jika AFK dinyalakan lewat perintah.
+	Manual *Manual
+	// Event berisi event kalender yang sedang berlangsung, jika AFK karena kalender.
+	Event *Occurrence
+	// Until adalah akhir jadwal atau event AFK; kosong untuk AFK manual.
+	Until time.Time
+}
+
+// Vars adalah nilai placeholder untuk template pesan AFK.
+type Vars struct {
+	Name   string // {name}: nama owner
+	Sender string // {sender}: nama pengirim pesan
+	Until  string // {until}: waktu AFK berakhir
+	Reason string // {reason}: alasan AFK manual
+	Since  string // {since}: waktu AFK manual dimulai
+	Event  string // {event}: nama event kalender
+}
+
+// Render mengganti placeholder {name}, {sender}, {until}, {reason}, {since} dan {event} di template.
+func Render(template string, v Vars) string {
+	return strings.NewReplacer(
+		"{name}", v.Name,
+		"{sender}", v.Sender,
+		"{until}", v.Until,
+		"{reason}", v.Reason,
+		"{since}", v.Since,
+		"{event}", v.Event,
+	).Replace(template)
+}
+
+// Manager memegang pengaturan AFK beserta zona waktunya dan menyimpannya lewat Store.
+type Manager struct {
+	mu       sync.RWMutex
+	state    State
+	loc      *time.Location
+	renotify time.Duration
+	store    Store
+	// calendar boleh nil jika tidak ada file kalender yang dikonfigurasi.
+	calendar *Calendar
+
+	// missedDirty menandai pesan terlewat yang belum disimpan; lihat RecordMissed.
+	missedDirty    bool
+	missedSavedAt  time.Time
+	missedFlushing *time.Timer
+}
+
+// NewManager memuat state dari store. Jika store belum berisi pengaturan,
+// defaults (biasanya dari config) dipakai. calendar boleh nil.
+func NewManager(ctx context.Context, store Store, defaults Settings, calendar *Calendar) (*Manager, error) {
+	state, err := store.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gagal memuat state AFK: %w", err)
+	}
+	if state == nil {
+		state = &State{}
+	}
+	if state.Settings.Timezone == "" && state.Settings.Schedule == nil && state.Settings.Message == "" {
+		state.Settings = defaults
+	}
+	// State lama belum punya template manual.
+	if state.Settings.ManualMessage == "" {
+		state.Settings.ManualMessage = defaults.ManualMessage
+	}
+	if state.Settings.CalendarMessage == "" {
+		state.Settings.CalendarMessage = defaults.CalendarMessage
+	}
+	if state.Settings.RenotifyInterval == "" {
+		state.Settings.RenotifyInterval = defaults.RenotifyInterval
+	}
+
+	loc, err := time.LoadLocation(state.Settings.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("gagal memuat zona waktu %s: %w", state.Settings.Timezone, err)
+	}
+	renotify, err := time.ParseDuration(state.Settings.RenotifyInterval)
+	if err != nil {
+		return nil, fmt.Errorf("gagal memuat interval balasan ulang %s: %w", state.Settings.RenotifyInterval, err)
+	}
+
+	return &Manager{state: *state, loc: loc, renotify: renotify, store: store, calendar: calendar}, nil
+}
+
+// Calendar mengembalikan kalender AFK, atau nil jika tidak dikonfigurasi.
+func (m *Manager) Calendar() *Calendar {
+	return m.calendar
+}
+
+// Settings mengembalikan salinan pengaturan saat ini.
+func (m *Manager) Settings() Settings {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	s := m.state.Settings
+	s.Schedule = append(Schedule(nil), s.Schedule...)
+	return s
+}
+
+// Location mengembalikan zona waktu AFK.
+func (m *Manager) Location() *time.Location {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.loc
+}
+
+// Status melaporkan apakah owner sedang AFK pada t, baik manual, karena event kalender,
+// maupun karena jadwal. AFK manual didahulukan karena tidak punya waktu berakhir,
+// lalu event kalender karena lebih spesifik daripada jadwal rutin.
+func (m *Manager) Status(t time.Time) Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status(t)
+}
+
+// status adalah Status tanpa lock; pemanggil harus sudah memegang m.mu.
+func (m *Manager) status(t time.Time) Status {
+	if m.state.Manual != nil {
+		manual := *m.state.Manual
+		return Status{Active: true, Manual: &manual}
+	}
+	if m.calendar != nil {
+		if occ, ok := m.calendar.ActiveAt(t, m.loc); ok {
+			return Status{Active: true, Event: &occ, Until: occ.End}
+		}
+	}
+	until, active := m.state.Settings.Schedule.ActiveAt(t.In(m.loc))
+	return Status{Active: active, Until: until}
+}
+
+// ClaimNotice melaporkan apakah chat perlu dibalas pesan AFK pada now, dan jika ya
+// mencatatnya sehingga pesan berikutnya dari chat yang sama tidak dibalas lagi sampai
+// RenotifyInterval berlalu atau periode AFK berganti. Catatan dari periode lama dibuang.
+// Jika catatan gagal disimpan, false dikembalikan agar chat tidak dibalas berulang kali.
+func (m *Manager) ClaimNotice(ctx context.Context, chatID string, now time.Time) (bool, error) {
+	var notify bool
+	err := m.update(ctx, func(s *State) error {
+		status := m.status(now)
+		if !status.Active {
+			return errUnchanged
+		}
+		period := status.Until
+		if status.Manual != nil {
+			period = status.Manual.Since
+		}
+
+		pruned := false
+		for id, notice := range s.Notified {
+			if !notice.Period.Equal(period) {
+				delete(s.Notified, id)
+				pruned = true
+			}
+		}
+
+		if last, ok := s.Notified[chatID]; ok && (m.renotify <= 0 || now.Sub(last.At) < m.renotify) {
+			if pruned {
+				return nil
+			}
+			return errUnchanged
+		}
+		if s.Notified == nil {
+			s.Notified = make(map[string]Notice)
+		}
+		s.Notified[chatID] = Notice{Period: period, At: now}
+		notify = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return notify, nil
+}
+
+// StartManual menyalakan AFK manual dengan alasan tersebut. Rangkuman pesan sebelumnya dibuang.
+func (m *Manager) StartManual(ctx context.Context, reason string, now time.Time) error {
+	return m.update(ctx, func(s *State) error {
+		s.Manual = &Manual{Since: now, Reason: reason}
+		s.Missed = nil
+		return nil
+	})
+}
+
+// EndManual mematikan AFK manual dan mengembalikan data AFK beserta pesan yang terlewat.
+// Jika AFK manual tidak aktif, nil dikembalikan tanpa menyimpan apa pun. Jika penyimpanan
+// gagal, AFK manual tetap aktif dan nil dikembalikan bersama error-nya.
+func (m *Manager) EndManual(ctx context.Context) (*Manual, []MissedMessage, error) {
+	var manual *Manual
+	var missed []MissedMessage
+	err := m.update(ctx, func(s *State) error {
+		if s.Manual == nil {
+			return errUnchanged
+		}
+		manual, missed = s.Manual, s.Missed
+		s.Manual, s.Missed = nil, nil
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return manual, missed, nil
+}
+
+// RecordMissed mencatat pesan yang masuk selama AFK manual untuk rangkuman. Pesan langsung
+// masuk ke state, tetapi penyimpanannya digabung: paling sering sekali per missedSaveInterval,
+// sisanya disimpan oleh timer, perubahan state berikutnya, atau Flush saat bot berhenti.
+func (m *Manager) RecordMissed(ctx context.Context, msg MissedMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.state.Manual == nil {
+		return nil
+	}
+	m.state.Missed = append(m.state.Missed, msg)
+	if len(m.state.Missed) > maxMissed {
+		m.state.Missed = m.state.Missed[len(m.state.Missed)-maxMissed:]
+	}
+	m.missedDirty = true
+
+	if wait := missedSaveInterval - time.Since(m.missedSavedAt); wait > 0 {
+		if m.missedFlushing == nil {
+			// Jika gagal, missedDirty tetap true dan pesan berikutnya langsung mencoba menyimpan
+			// lagi, sehingga error-nya sampai ke pemanggil RecordMissed.
+			m.missedFlushing = time.AfterFunc(wait, func() { _ = m.Flush(context.Background()) })
+		}
+		return nil
+	}
+	return m.saveLocked(ctx)
+}
+
+// Flush menyimpan pesan terlewat yang belum sempat disimpan oleh RecordMissed.
+func (m *Manager) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.missedFlushing = nil
+	if !m.missedDirty {
+		return nil
+	}
+	return m.saveLocked(ctx)
+}
+
+// SetSchedule mengganti jadwal AFK dan menyimpannya.
+func (m *Manager) SetSchedule(ctx context.Context, schedule Schedule) error {
+	return m.update(ctx, func(s *State) error {
+		s.Settings.Schedule = schedule
+		return nil
+	})
+}
+
+// SetTimezone mengganti zona waktu AFK dan menyimpannya.
+func (m *Manager) SetTimezone(ctx context.Context, name string) error {
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("zona waktu %q tidak dikenal: %w", name, err)
+	}
+	return m.update(ctx, func(s *State) error {
+		s.Settings.Timezone = name
+		return nil
+	})
+}
+
+// SetRenotifyInterval mengganti jeda balasan ulang per chat dan menyimpannya.
+func (m *Manager) SetRenotifyInterval(ctx context.Context, d time.Duration) error {
+	if d < 0 {
+		return fmt.Errorf("interval tidak boleh negatif")
+	}
+	return m.update(ctx, func(s *State) error {
+		s.Settings.RenotifyInterval = d.String()
+		return nil
+	})
+}
+
+// SetMessage mengganti template pesan AFK dan menyimpannya.
+func (m *Manager) SetMessage(ctx context.Context, message string) error {
+	return m.update(ctx, func(s *State) error {
+		s.Settings.Message = message
+		return nil
+	})
+}
+
+// update menerapkan perubahan ke salinan state, menyimpannya, lalu baru memakainya. Jika
+// penyimpanan gagal, state di memori tidak berubah sehingga tetap sama dengan yang tersimpan.
+// Lock ditahan selama penyimpanan agar urutan tulis sama dengan urutan perubahan. Jika fn
+// mengembalikan errUnchanged, penyimpanan dilewati dan nil dikembalikan.
+func (m *Manager) update(ctx context.Context, fn func(*State) error) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	next := m.state.clone()
+	if err := fn(&next); err != nil {
+		if errors.Is(err, errUnchanged) {
+			return nil
+		}
+		return err
+	}
+	if err := m.store.Save(ctx, &next); err != nil {
+		return err
+	}
+	m.commitLocked(next)
+	return nil
+}
+
+// commitLocked memakai state yang sudah tersimpan beserta nilai turunannya (zona waktu dan
+// interval balasan ulang). m.mu harus dipegang.
+func (m *Manager) commitLocked(next State) {
+	if next.Settings.Timezone != m.state.Settings.Timezone {
+		if loc, err := time.LoadLocation(next.Settings.Timezone); err == nil {
+			m.loc = loc
+		}
+	}
+	if d, err := time.ParseDuration(next.Settings.RenotifyInterval); err == nil {
+		m.renotify = d
+	}
+	m.state = next
+	m.missedDirty = false
+	m.missedSavedAt = time.Now()
+}
+
+// saveLocked menyimpan seluruh state, termasuk pesan terlewat yang tertunda. m.mu harus dipegang.
+func (m *Manager) saveLocked(ctx context.Context) error {
+	if err := m.store.Save(ctx, &m.state); err != nil {
+		return err
+	}
+	m.missedDirty = false
+	m.missedSavedAt = time.Now()
+	return nil
+}
+
+// DefaultSettings membangun pengaturan awal dari config.
+func DefaultSettings(cfg config.Config) (Settings, error) {
+	schedule, err := ParseSchedule(cfg.AFKSchedule)
+	if err != nil {
+		return Settings{}, fmt.Errorf("AFK_SCHEDULE: %w", err)
+	}
+	if _, err := time.ParseDuration(cfg.AFKRenotifyInterval); err != nil {
+		return Settings{}, fmt.Errorf("AFK_RENOTIFY_INTERVAL: %w", err)
+	}
+	return Settings{
+		Timezone:         cfg.AFKTimezone,
+		Schedule:         schedule,
+		Message:          cfg.AFKMessage,
+		ManualMessage:    cfg.AFKManualMessage,
+		CalendarMessage:  cfg.AFKCalendarMessage,
+		RenotifyInterval: cfg.AFKRenotifyInterval,
+	}, nil
+}
+
+// CalendarPaths mengembalikan daftar file kalender dari AFK_CALENDARS.
+func CalendarPaths(cfg config.Config) []string {
+	var paths []string
+	for _, path := range strings.Split(cfg.AFKCalendars, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
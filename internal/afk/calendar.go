@@ -0,0 +1,583 @@
+package afk
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// calendarCheckInterval adalah jeda minimum antar pengecekan perubahan file kalender.
+const calendarCheckInterval = time.Minute
+
+// maxOccurrences membatasi perulangan saat mencari kejadian dari event berulang. Untuk seri
+// tanpa COUNT batas ini dihitung dari langkah di sekitar waktu yang dicek, bukan dari DTSTART.
+const maxOccurrences = 10000
+
+// Event adalah satu VEVENT dari file iCalendar. Waktu tanpa zona (termasuk event seharian)
+// ditafsirkan dengan zona waktu AFK saat dicek.
+type Event struct {
+	Summary string
+	// Source adalah nama file asal event.
+	Source string
+	AllDay bool
+
+	start, end icsTime
+	rule       *recurrence
+	// exdates adalah kejadian yang dikecualikan lewat EXDATE.
+	exdates []exdate
+}
+
+// exdate adalah satu nilai EXDATE. Jika date bernilai true, seluruh kejadian pada tanggal
+// tersebut dikecualikan, bukan hanya yang mulai tepat pada jam yang sama.
+type exdate struct {
+	icsTime
+	date bool
+}
+
+// Occurrence adalah satu kejadian event pada waktu yang sudah pasti.
+type Occurrence struct {
+	Event
+	Start, End time.Time
+}
+
+// icsTime adalah nilai DTSTART/DTEND. Waktu "floating" dan tanggal belum punya zona
+// sehingga disimpan sebagai jam dinding dan baru dipasangkan ke zona saat dipakai.
+type icsTime struct {
+	t        time.Time
+	floating bool
+}
+
+func (v icsTime) in(loc *time.Location) time.Time {
+	if !v.floating {
+		return v.t
+	}
+	y, m, d := v.t.Date()
+	return time.Date(y, m, d, v.t.Hour(), v.t.Minute(), v.t.Second(), 0, loc)
+}
+
+// errUnsupportedRRule menandai RRULE dengan bagian yang belum didukung. Event seperti itu
+// dilewati alih-alih dijalankan dengan aturan yang keliru.
+var errUnsupportedRRule = errors.New("bagian RRULE tidak didukung")
+
+// icsWeekdays memetakan kode hari iCalendar ke time.Weekday.
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+// recurrence adalah subset RRULE yang didukung: FREQ, INTERVAL, COUNT, UNTIL, WKST, dan
+// BYDAY tanpa angka urutan untuk FREQ=DAILY atau WEEKLY.
+type recurrence struct {
+	freq     string
+	interval int
+	count    int
+	until    time.Time
+	byDay    []time.Weekday
+	wkst     time.Weekday
+}
+
+// next menggeser waktu satu langkah sesuai frekuensi perulangan.
+func (r *recurrence) next(t time.Time, n int) time.Time {
+	step := n * r.interval
+	switch r.freq {
+	case "DAILY":
+		return t.AddDate(0, 0, step)
+	case "WEEKLY":
+		return t.AddDate(0, 0, 7*step)
+	case "MONTHLY":
+		return t.AddDate(0, step, 0)
+	default: // YEARLY
+		return t.AddDate(step, 0, 0)
+	}
+}
+
+// expand mengembalikan waktu mulai kejadian pada langkah ke-n, berurutan. Tanpa BYDAY
+// hasilnya selalu satu kejadian; dengan BYDAY bisa nol (DAILY) atau beberapa (WEEKLY).
+func (r *recurrence) expand(start time.Time, n int) []time.Time {
+	base := r.next(start, n)
+	if len(r.byDay) == 0 {
+		return []time.Time{base}
+	}
+	if r.freq == "DAILY" {
+		if slices.Contains(r.byDay, base.Weekday()) {
+			return []time.Time{base}
+		}
+		return nil
+	}
+
+	// WEEKLY: semua hari BYDAY dalam minggu yang sama dengan base, dihitung dari WKST.
+	weekStart := base.AddDate(0, 0, -r.weekOffset(base.Weekday()))
+	var out []time.Time
+	for _, day := range r.byDay {
+		occ := weekStart.AddDate(0, 0, r.weekOffset(day))
+		if !occ.Before(start) {
+			out = append(out, occ)
+		}
+	}
+	return out
+}
+
+// firstStep mengembalikan langkah perulangan paling awal yang mungkin masih berlangsung pada t
+// untuk event sepanjang length. Hasilnya sengaja mundur satu langkah untuk menutup selisih
+// panjang bulan, pergantian DST dan hari BYDAY sebelum hari DTSTART dalam satu minggu.
+func (r *recurrence) firstStep(start, t time.Time, length time.Duration) int {
+	from := t.Add(-length).In(start.Location())
+	if !from.After(start) {
+		return 0
+	}
+	var steps int
+	switch r.freq {
+	case "DAILY":
+		steps = int(from.Sub(start).Hours() / 24)
+	case "WEEKLY":
+		steps = int(from.Sub(start).Hours() / (24 * 7))
+	case "MONTHLY":
+		steps = (from.Year()-start.Year())*12 + int(from.Month()) - int(start.Month())
+	default: // YEARLY
+		steps = from.Year() - start.Year()
+	}
+	return max(steps/r.interval-1, 0)
+}
+
+// weekOffset mengembalikan posisi hari dalam minggu yang dimulai pada WKST.
+func (r *recurrence) weekOffset(day time.Weekday) int {
+	return (int(day) - int(r.wkst) + 7) % 7
+}
+
+// activeAt mencari kejadian event yang sedang berlangsung pada t.
+func (e Event) activeAt(t time.Time, loc *time.Location) (Occurrence, bool) {
+	start, end := e.start.in(loc), e.end.in(loc)
+	if e.rule == nil {
+		if !t.Before(start) && t.Before(end) && !e.excluded(start, loc) {
+			return Occurrence{Event: e, Start: start, End: end}, true
+		}
+		return Occurrence{}, false
+	}
+
+	// COUNT dihitung sebelum EXDATE diterapkan, sesuai RFC 5545, sehingga seri dengan COUNT
+	// harus dijalani dari DTSTART. Seri tanpa COUNT langsung mulai dari sekitar t.
+	first := 0
+	if e.rule.count == 0 {
+		first = e.rule.firstStep(start, t, end.Sub(start))
+	}
+	emitted := 0
+	for n := first; n < first+maxOccurrences; n++ {
+		for _, occStart := range e.rule.expand(start, n) {
+			if e.rule.count > 0 && emitted >= e.rule.count {
+				return Occurrence{}, false
+			}
+			if occStart.After(t) || (!e.rule.until.IsZero() && occStart.After(e.rule.until)) {
+				return Occurrence{}, false
+			}
+			emitted++
+			occEnd := shiftEnd(start, end, occStart)
+			if t.Before(occEnd) && !e.excluded(occStart, loc) {
+				return Occurrence{Event: e, Start: occStart, End: occEnd}, true
+			}
+		}
+	}
+	return Occurrence{}, false
+}
+
+// shiftEnd menghitung akhir kejadian yang mulai pada occStart dengan selisih tanggal dan jam
+// dinding yang sama seperti start dan end, agar durasi tetap benar saat melewati pergantian DST.
+func shiftEnd(start, end, occStart time.Time) time.Time {
+	end = end.In(start.Location())
+	sy, sm, sd := start.Date()
+	ey, em, ed := end.Date()
+	days := int(time.Date(ey, em, ed, 0, 0, 0, 0, time.UTC).Sub(time.Date(sy, sm, sd, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+	y, m, d := occStart.Date()
+	return time.Date(y, m, d+days, end.Hour(), end.Minute(), end.Second(), 0, occStart.Location())
+}
+
+// excluded melaporkan apakah kejadian yang mulai pada occStart dikecualikan lewat EXDATE.
+func (e Event) excluded(occStart time.Time, loc *time.Location) bool {
+	for _, ex := range e.exdates {
+		if !ex.date {
+			if ex.in(loc).Equal(occStart) {
+				return true
+			}
+			continue
+		}
+		y, m, d := occStart.In(loc).Date()
+		if ey, em, ed := ex.t.Date(); y == ey && m == em && d == ed {
+			return true
+		}
+	}
+	return false
+}
+
+// Calendar memuat event dari satu atau lebih file .ics dan memuat ulang otomatis
+// ketika file berubah.
+type Calendar struct {
+	paths []string
+
+	mu       sync.RWMutex
+	events   []Event
+	modTimes map[string]time.Time
+	checked  time.Time
+	lastErr  error
+}
+
+// NewCalendar membuat kalender dari daftar path dan langsung memuatnya.
+// Path yang gagal dimuat tidak menghentikan path lain; error-nya dikembalikan sebagai gabungan.
+func NewCalendar(paths []string) (*Calendar, error) {
+	c := &Calendar{paths: paths}
+	_, err := c.Reload()
+	return c, err
+}
+
+// Paths mengembalikan daftar file kalender.
+func (c *Calendar) Paths() []string {
+	return c.paths
+}
+
+// Reload membaca ulang semua file kalender dan mengembalikan jumlah event yang dimuat.
+// Jika sebagian file gagal dibaca, event dari file lain tetap dipakai.
+func (c *Calendar) Reload() (int, error) {
+	var events []Event
+	var errs []error
+	modTimes := make(map[string]time.Time, len(c.paths))
+
+	for _, path := range c.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		modTimes[path] = info.ModTime()
+
+		// Event yang dilewati dilaporkan sebagai error, tetapi event lain dari file yang sama tetap dipakai.
+		fileEvents, err := parseICSFile(path)
+		events = append(events, fileEvents...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+
+	err := errors.Join(errs...)
+	c.mu.Lock()
+	c.events = events
+	c.modTimes = modTimes
+	c.checked = time.Now()
+	c.lastErr = err
+	c.mu.Unlock()
+	return len(events), err
+}
+
+// LastError mengembalikan error dari pemuatan terakhir, jika ada.
+func (c *Calendar) LastError() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastErr
+}
+
+// ActiveAt mengembalikan event yang sedang berlangsung pada t. Jika beberapa event
+// tumpang tindih, yang berakhir paling lambat dipilih.
+func (c *Calendar) ActiveAt(t time.Time, loc *time.Location) (Occurrence, bool) {
+	c.maybeReload()
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var best Occurrence
+	found := false
+	for _, e := range c.events {
+		occ, ok := e.activeAt(t, loc)
+		if ok && (!found || occ.End.After(best.End)) {
+			best, found = occ, true
+		}
+	}
+	return best, found
+}
+
+// maybeReload memuat ulang kalender jika ada file yang berubah sejak pemuatan terakhir.
+// Pengecekan dibatasi sekali per calendarCheckInterval.
+func (c *Calendar) maybeReload() {
+	c.mu.Lock()
+	if time.Since(c.checked) < calendarCheckInterval {
+		c.mu.Unlock()
+		return
+	}
+	c.checked = time.Now()
+	modTimes := c.modTimes
+	c.mu.Unlock()
+
+	for _, path := range c.paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().Equal(modTimes[path]) {
+			c.Reload()
+			return
+		}
+	}
+}
+
+// parseICSFile membaca semua VEVENT dari file iCalendar.
+func parseICSFile(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines, err := unfoldICS(f)
+	if err != nil {
+		return nil, err
+	}
+
+	source := filepath.Base(path)
+	var events []Event
+	var current *Event
+	var hasEnd bool
+	var duration time.Duration
+	// skipped berisi alasan event saat ini dilewati; event lain di file tetap dimuat.
+	var skipped error
+	var warnings []error
+	var beginLine int
+	// components adalah tumpukan komponen yang sedang terbuka, agar properti di dalam
+	// subkomponen seperti VALARM tidak diterapkan ke VEVENT induknya.
+	var components []string
+
+	for i, line := range lines {
+		name, params, value := splitICSLine(line)
+		switch name {
+		case "BEGIN":
+			component := strings.ToUpper(value)
+			components = append(components, component)
+			if component == "VEVENT" {
+				current = &Event{Source: source}
+				hasEnd, duration, skipped, beginLine = false, 0, nil, i+1
+			}
+			continue
+		case "END":
+			component := strings.ToUpper(value)
+			// END tanpa BEGIN yang cocok diabaikan; komponen yang tidak ditutup ikut ditutup.
+			if idx := slices.Index(components, component); idx >= 0 {
+				components = components[:idx]
+			}
+			if component != "VEVENT" || current == nil {
+				if !slices.Contains(components, "VEVENT") {
+					// VEVENT yang tidak pernah ditutup dibuang.
+					current = nil
+				}
+				continue
+			}
+			if skipped == nil && current.start.t.IsZero() {
+				skipped = errors.New("tanpa DTSTART")
+			}
+			if skipped != nil {
+				warnings = append(warnings, fmt.Errorf("baris %d: event %q dilewati: %w", beginLine, current.Summary, skipped))
+				current = nil
+				continue
+			}
+			if !hasEnd {
+				current.end = current.start
+				switch {
+				case duration > 0:
+					current.end.t = current.start.t.Add(duration)
+				case current.AllDay:
+					current.end.t = current.start.t.AddDate(0, 0, 1)
+				}
+			}
+			events = append(events, *current)
+			current = nil
+			continue
+		}
+		if current == nil || len(components) == 0 || components[len(components)-1] != "VEVENT" {
+			continue
+		}
+
+		switch name {
+		case "SUMMARY":
+			current.Summary = unescapeICS(value)
+		case "DTSTART":
+			current.start, current.AllDay, err = parseICSTime(value, params)
+		case "DTEND":
+			current.end, _, err = parseICSTime(value, params)
+			hasEnd = true
+		case "DURATION":
+			duration, err = parseICSDuration(value)
+		case "RRULE":
+			current.rule, err = parseRRule(value)
+		case "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				var ex exdate
+				ex.icsTime, ex.date, err = parseICSTime(v, params)
+				if err != nil {
+					break
+				}
+				current.exdates = append(current.exdates, ex)
+			}
+		}
+		// Nilai yang tidak valid atau RRULE yang tidak didukung hanya melewati event ini,
+		// agar satu entri rusak tidak mematikan seluruh kalender.
+		if err != nil && skipped == nil {
+			skipped = fmt.Errorf("baris %d: %w", i+1, err)
+		}
+		err = nil
+	}
+	return events, errors.Join(warnings...)
+}
+
+// unfoldICS menggabungkan baris lanjutan (diawali spasi atau tab) sesuai RFC 5545.
+func unfoldICS(f *os.File) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// splitICSLine memecah "NAME;PARAM=x:VALUE" menjadi nama, parameter dan nilai.
+func splitICSLine(line string) (name string, params map[string]string, value string) {
+	head, value, _ := strings.Cut(line, ":")
+	parts := strings.Split(head, ";")
+	params = make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, value
+}
+
+// unescapeICS mengembalikan karakter yang di-escape pada nilai teks iCalendar.
+func unescapeICS(s string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
+
+// parseICSTime membaca nilai DATE atau DATE-TIME beserta parameter TZID.
+func parseICSTime(value string, params map[string]string) (icsTime, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.Parse("20060102", value)
+		if err != nil {
+			return icsTime{}, false, fmt.Errorf("tanggal %q tidak valid", value)
+		}
+		return icsTime{t: t, floating: true}, true, nil
+	}
+
+	if utc, ok := strings.CutSuffix(value, "Z"); ok {
+		t, err := time.Parse("20060102T150405", utc)
+		if err != nil {
+			return icsTime{}, false, fmt.Errorf("waktu %q tidak valid", value)
+		}
+		return icsTime{t: t}, false, nil
+	}
+
+	if tzid := params["TZID"]; tzid != "" {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			t, err := time.ParseInLocation("20060102T150405", value, loc)
+			if err != nil {
+				return icsTime{}, false, fmt.Errorf("waktu %q tidak valid", value)
+			}
+			return icsTime{t: t}, false, nil
+		}
+		// TZID non-standar (misal dari Outlook) dianggap floating.
+	}
+
+	t, err := time.Parse("20060102T150405", value)
+	if err != nil {
+		return icsTime{}, false, fmt.Errorf("waktu %q tidak valid", value)
+	}
+	return icsTime{t: t, floating: true}, false, nil
+}
+
+var icsDurationRe = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseICSDuration membaca DURATION seperti "P1D", "PT1H30M" atau "P2W".
+func parseICSDuration(value string) (time.Duration, error) {
+	m := icsDurationRe.FindStringSubmatch(value)
+	if m == nil || m[1] == "-" {
+		return 0, fmt.Errorf("durasi %q tidak valid", value)
+	}
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var d time.Duration
+	for i, unit := range units {
+		if m[i+2] == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(m[i+2])
+		d += time.Duration(n) * unit
+	}
+	return d, nil
+}
+
+// parseRRule membaca RRULE. Bagian di luar subset yang didukung (lihat recurrence), misal
+// BYMONTHDAY, BYSETPOS atau BYDAY dengan angka urutan, menghasilkan errUnsupportedRRule.
+func parseRRule(value string) (*recurrence, error) {
+	r := &recurrence{interval: 1, wkst: time.Monday}
+	for _, part := range strings.Split(value, ";") {
+		k, v, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(k) {
+		case "FREQ":
+			r.freq = strings.ToUpper(v)
+		case "INTERVAL":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("INTERVAL %q tidak valid", v)
+			}
+			r.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("COUNT %q tidak valid", v)
+			}
+			r.count = n
+		case "UNTIL":
+			until, isDate, err := parseICSTime(v, nil)
+			if err != nil {
+				return nil, err
+			}
+			// UNTIL tanpa zona dianggap UTC; cukup akurat untuk batas perulangan.
+			// UNTIL berupa tanggal mencakup seluruh hari tersebut.
+			r.until = until.t
+			if isDate {
+				r.until = r.until.AddDate(0, 0, 1)
+			}
+		case "BYDAY":
+			for _, code := range strings.Split(strings.ToUpper(v), ",") {
+				day, ok := icsWeekdays[code]
+				if !ok {
+					// Termasuk bentuk berurutan seperti 1MO atau -1FR.
+					return nil, fmt.Errorf("%w: BYDAY=%s", errUnsupportedRRule, v)
+				}
+				if !slices.Contains(r.byDay, day) {
+					r.byDay = append(r.byDay, day)
+				}
+			}
+		case "WKST":
+			day, ok := icsWeekdays[strings.ToUpper(v)]
+			if !ok {
+				return nil, fmt.Errorf("WKST %q tidak valid", v)
+			}
+			r.wkst = day
+		default:
+			return nil, fmt.Errorf("%w: %s", errUnsupportedRRule, strings.ToUpper(k))
+		}
+	}
+	switch r.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("FREQ %q tidak didukung", r.freq)
+	}
+	if len(r.byDay) > 0 && r.freq != "DAILY" && r.freq != "WEEKLY" {
+		return nil, fmt.Errorf("%w: BYDAY dengan FREQ=%s", errUnsupportedRRule, r.freq)
+	}
+	// Urutkan hari sesuai posisinya dalam minggu agar kejadian dihasilkan berurutan.
+	slices.SortFunc(r.byDay, func(a, b time.Weekday) int { return r.weekOffset(a) - r.weekOffset(b) })
+	return r, nil
+}
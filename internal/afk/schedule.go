@@ -0,0 +1,220 @@
+package afk
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Clock adalah jam dalam sehari, disimpan sebagai menit sejak 00:00.
+// Diserialisasi sebagai teks "HH:MM" agar file/DB mudah dibaca.
+type Clock int
+
+// ParseClock membaca format "HH:MM" atau "HH.MM".
+func ParseClock(s string) (Clock, error) {
+	s = strings.Replace(strings.TrimSpace(s), ".", ":", 1)
+	hh, mm, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("jam %q tidak valid, gunakan HH:MM", s)
+	}
+	h, err1 := strconv.Atoi(hh)
+	m, err2 := strconv.Atoi(mm)
+	if err1 != nil || err2 != nil || h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("jam %q tidak valid, gunakan HH:MM", s)
+	}
+	return Clock(h*60 + m), nil
+}
+
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", int(c)/60, int(c)%60)
+}
+
+func (c Clock) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Clock) UnmarshalText(b []byte) error {
+	parsed, err := ParseClock(string(b))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// on mengembalikan waktu pada tanggal day (di zona day) dengan jam c.
+func (c Clock) on(day time.Time) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, int(c)/60, int(c)%60, 0, 0, day.Location())
+}
+
+// Window adalah satu rentang AFK. Jika End <= Start, rentang melewati tengah malam
+// dan berakhir keesokan harinya. Days adalah hari dimulainya rentang; kosong berarti setiap hari.
+type Window struct {
+	Days  []time.Weekday `json:"days,omitempty"`
+	Start Clock          `json:"start"`
+	End   Clock          `json:"end"`
+}
+
+// startsOn melaporkan apakah rentang dimulai pada hari tersebut.
+func (w Window) startsOn(day time.Weekday) bool {
+	return len(w.Days) == 0 || slices.Contains(w.Days, day)
+}
+
+// occurrence mengembalikan awal dan akhir rentang yang dimulai pada tanggal day.
+func (w Window) occurrence(day time.Time) (start, end time.Time) {
+	start = w.Start.on(day)
+	end = w.End.on(day)
+	if !end.After(start) {
+		end = w.End.on(day.AddDate(0, 0, 1))
+	}
+	return start, end
+}
+
+// activeAt mengembalikan akhir rentang jika t berada di dalamnya.
+// Rentang yang dimulai kemarin ikut diperiksa untuk kasus lewat tengah malam.
+func (w Window) activeAt(t time.Time) (time.Time, bool) {
+	for _, day := range []time.Time{t.AddDate(0, 0, -1), t} {
+		if !w.startsOn(day.Weekday()) {
+			continue
+		}
+		start, end := w.occurrence(day)
+		if !t.Before(start) && t.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (w Window) String() string {
+	return fmt.Sprintf("%s %s-%s", formatDays(w.Days), w.Start, w.End)
+}
+
+// Schedule adalah kumpulan rentang AFK.
+type Schedule []Window
+
+// ActiveAt mengembalikan akhir rentang AFK yang sedang berlangsung pada t.
+// Jika beberapa rentang tumpang tindih, akhir yang paling lambat dipakai.
+func (s Schedule) ActiveAt(t time.Time) (until time.Time, ok bool) {
+	for _, w := range s {
+		if end, active := w.activeAt(t); active && end.After(until) {
+			until, ok = end, true
+		}
+	}
+	return until, ok
+}
+
+func (s Schedule) String() string {
+	parts := make([]string, len(s))
+	for i, w := range s {
+		parts[i] = w.String()
+	}
+	return strings.Join(parts, "; ")
+}
+
+// dayNames memetakan nama hari (Inggris dan Indonesia) ke time.Weekday.
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "min": time.Sunday,
+	"mon": time.Monday, "sen": time.Monday,
+	"tue": time.Tuesday, "sel": time.Tuesday,
+	"wed": time.Wednesday, "rab": time.Wednesday,
+	"thu": time.Thursday, "kam": time.Thursday,
+	"fri": time.Friday, "jum": time.Friday,
+	"sat": time.Saturday, "sab": time.Saturday,
+}
+
+var shortDayNames = [...]string{"min", "sen", "sel", "rab", "kam", "jum", "sab"}
+
+// ParseSchedule membaca jadwal seperti "mon-fri 22:00-07:00; sat,sun 00:00-09:00".
+// Setiap rentang dipisah ";" dan boleh diawali daftar hari (sen-jum, sat,sun, daily).
+// Tanpa daftar hari, rentang berlaku setiap hari.
+func ParseSchedule(spec string) (Schedule, error) {
+	var schedule Schedule
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Fields(part)
+		var days []time.Weekday
+		if len(fields) == 2 {
+			var err error
+			days, err = parseDays(fields[0])
+			if err != nil {
+				return nil, err
+			}
+		} else if len(fields) != 1 {
+			return nil, fmt.Errorf("rentang %q tidak valid, gunakan \"[hari] HH:MM-HH:MM\"", part)
+		}
+
+		startStr, endStr, ok := strings.Cut(fields[len(fields)-1], "-")
+		if !ok {
+			return nil, fmt.Errorf("rentang jam %q tidak valid, gunakan HH:MM-HH:MM", fields[len(fields)-1])
+		}
+		start, err := ParseClock(startStr)
+		if err != nil {
+			return nil, err
+		}
+		end, err := ParseClock(endStr)
+		if err != nil {
+			return nil, err
+		}
+		schedule = append(schedule, Window{Days: days, Start: start, End: end})
+	}
+	return schedule, nil
+}
+
+// parseDays membaca daftar hari dipisah koma, dengan rentang seperti "mon-fri".
+func parseDays(spec string) ([]time.Weekday, error) {
+	spec = strings.ToLower(spec)
+	if spec == "daily" || spec == "setiap" || spec == "*" {
+		return nil, nil
+	}
+
+	var days []time.Weekday
+	for _, item := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		first, ok := dayNames[prefix3(from)]
+		if !ok {
+			return nil, fmt.Errorf("hari %q tidak dikenal", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = dayNames[prefix3(to)]; !ok {
+				return nil, fmt.Errorf("hari %q tidak dikenal", to)
+			}
+		}
+		// Rentang boleh melewati akhir pekan, misal "fri-mon".
+		for d := first; ; d = (d + 1) % 7 {
+			if !slices.Contains(days, d) {
+				days = append(days, d)
+			}
+			if d == last {
+				break
+			}
+		}
+	}
+	slices.Sort(days)
+	return days, nil
+}
+
+func prefix3(s string) string {
+	if len(s) > 3 {
+		return s[:3]
+	}
+	return s
+}
+
+func formatDays(days []time.Weekday) string {
+	if len(days) == 0 || len(days) == 7 {
+		return "setiap"
+	}
+	names := make([]string, len(days))
+	for i, d := range days {
+		names[i] = shortDayNames[d]
+	}
+	return strings.Join(names, ",")
+}
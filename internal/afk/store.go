@@ -0,0 +1,110 @@
+package afk
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/Satr10/wa-userbot/internal/storage"
+	"github.com/bytedance/sonic"
+)
+
+// Store adalah tempat penyimpanan state AFK.
+// Load mengembalikan nil tanpa error jika belum ada state tersimpan.
+type Store interface {
+	Load(ctx context.Context) (*State, error)
+	Save(ctx context.Context, state *State) error
+}
+
+// NewStore memilih implementasi Store sesuai jenis backend.
+func NewStore(ctx context.Context, backend *storage.Backend) (Store, error) {
+	switch backend.Kind {
+	case storage.KindPostgres:
+		return NewPostgresStore(ctx, backend.DB)
+	case storage.KindJSON:
+		return NewJSONStore(backend.Path("afk.json")), nil
+	default:
+		return nil, fmt.Errorf("storage backend %q tidak didukung untuk AFK", backend.Kind)
+	}
+}
+
+// JSONStore menyimpan state AFK sebagai satu file JSON.
+type JSONStore struct {
+	filePath string
+}
+
+// NewJSONStore membuat store berbasis file JSON di path tersebut.
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{filePath: path}
+}
+
+func (s *JSONStore) Load(ctx context.Context) (*State, error) {
+	file, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var state State
+	if err := sonic.Unmarshal(file, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (s *JSONStore) Save(ctx context.Context, state *State) error {
+	raw, err := sonic.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFileAtomic(s.filePath, raw)
+}
+
+// afkMigrations adalah skema tabel AFK. Jangan ubah entri lama, tambahkan versi baru di akhir.
+var afkMigrations = []string{
+	`CREATE TABLE afk_state (
+		id   SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+		data JSONB NOT NULL
+	);`,
+}
+
+// PostgresStore menyimpan state AFK sebagai satu baris JSONB.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore membuat store Postgres dan menjalankan migrasi skema yang belum diterapkan.
+func NewPostgresStore(ctx context.Context, db *sql.DB) (*PostgresStore, error) {
+	if err := storage.Migrate(ctx, db, "afk", afkMigrations); err != nil {
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+func (s *PostgresStore) Load(ctx context.Context) (*State, error) {
+	var raw []byte
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM afk_state WHERE id = 1`).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca state AFK: %w", err)
+	}
+	var state State
+	if err := sonic.Unmarshal(raw, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (s *PostgresStore) Save(ctx context.Context, state *State) error {
+	raw, err := sonic.Marshal(state)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO afk_state (id, data) VALUES (1, $1)
+		ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data`, raw)
+	return err
+}
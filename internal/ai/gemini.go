@@ -75,9 +75,15 @@ Definisi Tools yang Tersedia:
 
     check_google_safe_browsing: Argumen: {"url": "string"}
 
-    fetch_page_content: Argumen: {"url": "string"}
+    fetch_page_content: Argumen: {"url": "string"} (ringkasan halaman: judul, meta, form dan tujuan action, input password/kartu, host script eksternal, iframe, merek yang disebut, cuplikan teks dan hash favicon)
 
     lexical_analysis: Argumen: {"url": "string"}
+
+    trace_redirects: Argumen: {"url": "string"} (mengikuti seluruh rantai redirect HTTP, meta refresh dan JavaScript; lebih lengkap daripada resolve_short_url)
+
+    inspect_tls_certificate: Argumen: {"url": "string"} (penerbit, masa berlaku, umur sertifikat, daftar SAN, self-signed, host tidak cocok dan CA gratis; sertifikat gratis yang baru terbit pada domain tiruan adalah sinyal phishing kuat)
+
+    check_threat_feeds: Argumen: {"url": "string"} (mencocokkan URL dengan daftar phishing/malware lokal; "listed": true adalah bukti kuat)
 `
 
 type Gemini struct {
@@ -88,16 +94,19 @@ type Gemini struct {
 	log               *slog.Logger
 	tools             *aitools.Tools
 	chatMutex         sync.RWMutex
+	// probeSlots membatasi berapa HeuristicScan yang boleh menjalankan pemeriksaan jaringan bersamaan.
+	probeSlots chan struct{}
 }
 
 type URLScanResult struct {
-	InvestigationID string `json:"investigation_id"`
-	Status          string `json:"status"`
-	Reasoning       string `json:"reasoning"`
-	ToolCalls       []struct {
-		ToolName  string            `json:"tool_name"`
-		Arguments map[string]string `json:"arguments"`
-	} `json:"tool_calls"`
+	// URL adalah bentuk kanonik URL yang dipindai; diisi oleh pemanggil, bukan oleh model.
+	URL             string     `json:"url,omitempty"`
+	InvestigationID string     `json:"investigation_id"`
+	Status          string     `json:"status"`
+	Reasoning       string     `json:"reasoning"`
+	ToolCalls       []ToolCall `json:"tool_calls"`
+	// Heuristic bernilai true jika verdict dibuat oleh HeuristicScan karena Gemini tidak tersedia.
+	Heuristic    bool `json:"heuristic,omitempty"`
 	FinalVerdict struct {
 		Category        string  `json:"category"`
 		Explanation     string  `json:"explanation"`
@@ -105,6 +114,11 @@ type URLScanResult struct {
 	} `json:"final_verdict"`
 }
 
+type ToolCall struct {
+	ToolName  string            `json:"tool_name"`
+	Arguments map[string]string `json:"arguments"`
+}
+
 // NewGemini uses an initialized Tools object.
 func NewGemini(ctx context.Context, geminiApiKey, systemInstruction string, tools *aitools.Tools) (*Gemini, error) {
 	client, err := genai.NewClient(ctx, &genai.ClientConfig{
@@ -122,6 +136,7 @@ func NewGemini(ctx context.Context, geminiApiKey, systemInstruction string, tool
 		tools:             tools,
 		log:               logger.Get(),
 		chatMutex:         sync.RWMutex{},
+		probeSlots:        make(chan struct{}, maxHeuristicProbes),
 	}, nil
 }
 
@@ -240,6 +255,12 @@ func (g *Gemini) checkAndExecuteTools(resp *URLScanResult) (map[string]interface
 			result, err = g.tools.CheckGoogleSafeBrowsing(tool.Arguments["url"])
 		case "fetch_page_content":
 			result, err = g.tools.FetchPageContent(tool.Arguments["url"])
+		case "trace_redirects":
+			result, err = g.tools.TraceRedirects(tool.Arguments["url"])
+		case "inspect_tls_certificate":
+			result, err = g.tools.InspectTLSCertificate(tool.Arguments["url"])
+		case "check_threat_feeds":
+			result, err = g.tools.CheckThreatFeeds(tool.Arguments["url"])
 		case "lexical_analysis":
 			// Jalankan analisis seperti biasa, yang mengembalikan struct
 			analysisResult, lexErr := g.tools.LexicalAnalysis(tool.Arguments["url"])
@@ -392,6 +413,11 @@ func (r *URLScanResult) FormatWhatsAppMessage() string {
 	statusEmoji := getStatusEmoji(r.Status)
 	sb.WriteString(fmt.Sprintf("📋 *Status:* %s _%s_\n", statusEmoji, r.Status))
 
+	// URL kanonik ditampilkan dalam blok kode agar tidak menjadi link yang bisa diklik.
+	if r.URL != "" {
+		sb.WriteString(fmt.Sprintf("🔗 *URL:* ```%s```\n", r.URL))
+	}
+
 	// Investigation ID (shortened for readability)
 	if r.InvestigationID != "" {
 		shortID := r.InvestigationID
@@ -408,6 +434,10 @@ func (r *URLScanResult) FormatWhatsAppMessage() string {
 	sb.WriteString("║     *FINAL VERDICT* ║\n")
 	sb.WriteString("╚══════════════════╝\n\n")
 
+	if r.Heuristic {
+		sb.WriteString("⚙️ _Verdict heuristik: analisis AI sedang tidak tersedia, hasil ini dari pemeriksaan otomatis dasar._\n\n")
+	}
+
 	// Category with visual indicator
 	categoryDisplay := formatCategory(r.FinalVerdict.Category)
 	sb.WriteString(fmt.Sprintf("⚡ *Category:* %s\n\n", categoryDisplay))
@@ -0,0 +1,176 @@
+package ai
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	aitools "github.com/Satr10/wa-userbot/internal/ai_tools"
+)
+
+// Ambang skor heuristik. Skor adalah jumlah poin LexicalAnalysis ditambah poin rantai redirect dan umur domain.
+const (
+	heuristicPhishingScore   = 7
+	heuristicSuspiciousScore = 4
+)
+
+// maxHeuristicProbes adalah jumlah HeuristicScan yang boleh menjalankan trace redirect dan WHOIS
+// bersamaan. Saat penuh (misal banjir link yang kena rate limit), pemindaian berikutnya hanya
+// memakai threat feed dan analisis lexical agar fallback tidak ikut membanjiri jaringan.
+const maxHeuristicProbes = 2
+
+// HeuristicScan membuat verdict berbasis aturan tanpa Gemini, dipakai saat URLScan gagal
+// (misal kuota habis atau layanan gangguan). Hasilnya ditandai Heuristic dan tidak pernah
+// menyatakan SAFE: skor rendah dilaporkan sebagai UNKNOWN.
+func (g *Gemini) HeuristicScan(rawURL, id string) *URLScanResult {
+	h := &heuristic{tools: g.tools}
+	select {
+	case g.probeSlots <- struct{}{}:
+		defer func() { <-g.probeSlots }()
+		h.network = true
+	default:
+		h.findings = append(h.findings, "network_checks_skipped")
+	}
+	result := h.run(rawURL)
+	result.InvestigationID = id
+	g.log.Info("Verdict heuristik", "id", id, "category", result.FinalVerdict.Category, "score", h.score)
+	return result
+}
+
+// heuristic mengumpulkan poin dan temuan dari setiap pemeriksaan.
+type heuristic struct {
+	tools    *aitools.Tools
+	score    int
+	findings []string
+	checks   []ToolCall
+	// network bernilai false jika trace redirect dan WHOIS dilewati karena slot pemeriksaan penuh.
+	network bool
+}
+
+func (h *heuristic) run(rawURL string) *URLScanResult {
+	if result := h.checkFeeds(rawURL); result != nil {
+		return result
+	}
+	lexScore := h.lexical(rawURL)
+
+	if !h.network {
+		h.score += lexScore
+		return h.verdict()
+	}
+
+	target := rawURL
+	h.record("trace_redirects", rawURL)
+	if trace, err := h.tools.TraceRedirects(rawURL); err != nil {
+		h.findings = append(h.findings, fmt.Sprintf("redirect_error:%v", err))
+	} else if final := trace.FinalURL; final != rawURL {
+		target = final
+		if trace.CrossDomainHops > 0 {
+			// Satu lompatan antar-domain wajar untuk shortener; rantai lebih panjang khas kit phishing.
+			h.score += min(trace.CrossDomainHops, 3)
+			h.findings = append(h.findings, fmt.Sprintf("redirect_to:%s (cross_domain_hops:%d)", hostOf(final), trace.CrossDomainHops))
+		}
+		if trace.Loop || trace.LimitReached {
+			h.score++
+			h.findings = append(h.findings, fmt.Sprintf("redirect_hops:%d", len(trace.Hops)))
+		}
+		if result := h.checkFeeds(final); result != nil {
+			return result
+		}
+		// Skor lexical diambil yang tertinggi agar shortener tidak menutupi tujuan yang mencurigakan.
+		if finalScore := h.lexical(final); finalScore > lexScore {
+			lexScore = finalScore
+		}
+	}
+	h.score += lexScore
+
+	if host := hostOf(target); host != "" && net.ParseIP(host) == nil {
+		h.checks = append(h.checks, ToolCall{ToolName: "get_whois_data", Arguments: map[string]string{"domain": host}})
+		if created, err := h.tools.GetDomainCreated(host); err == nil {
+			age := time.Since(created)
+			days := int(age.Hours() / 24)
+			switch {
+			case age < 30*24*time.Hour:
+				h.score += 3
+				h.findings = append(h.findings, fmt.Sprintf("domain_age_days:%d", days))
+			case age < 180*24*time.Hour:
+				h.score++
+				h.findings = append(h.findings, fmt.Sprintf("domain_age_days:%d", days))
+			}
+		}
+	}
+	return h.verdict()
+}
+
+// verdict mengubah skor yang terkumpul menjadi kategori.
+func (h *heuristic) verdict() *URLScanResult {
+	result := h.result()
+	switch {
+	case h.score >= heuristicPhishingScore:
+		result.FinalVerdict.Category = "PHISHING"
+		result.FinalVerdict.ConfidenceScore = 0.7
+		result.FinalVerdict.Explanation = "Pemeriksaan otomatis menemukan banyak ciri khas link phishing (lihat analisis teknis). Jangan buka link ini dan jangan masukkan data apa pun."
+	case h.score >= heuristicSuspiciousScore:
+		result.FinalVerdict.Category = "SUSPICIOUS"
+		result.FinalVerdict.ConfidenceScore = 0.6
+		result.FinalVerdict.Explanation = "Link ini memiliki beberapa ciri mencurigakan. Sebaiknya jangan dibuka kecuali Anda yakin dengan pengirimnya."
+	default:
+		result.FinalVerdict.Category = "UNKNOWN"
+		result.FinalVerdict.ConfidenceScore = 0.3
+		result.FinalVerdict.Explanation = "Pemeriksaan dasar tidak menemukan tanda bahaya, tetapi link belum dianalisis penuh. Tetap berhati-hati."
+	}
+	return result
+}
+
+// checkFeeds mengembalikan verdict jika URL tercatat di threat feed.
+func (h *heuristic) checkFeeds(rawURL string) *URLScanResult {
+	h.record("check_threat_feeds", rawURL)
+	feed, err := h.tools.CheckThreatFeeds(rawURL)
+	if err != nil || !feed.Listed {
+		return nil
+	}
+	h.findings = append(h.findings, fmt.Sprintf("threat_feed:%s %s", feed.Match.Feed, feed.Match.Indicator))
+
+	result := h.result()
+	result.FinalVerdict.Category = feed.Match.Category
+	result.FinalVerdict.ConfidenceScore = 0.9
+	result.FinalVerdict.Explanation = fmt.Sprintf("Link ini tercatat sebagai %s di daftar ancaman %s. Jangan buka link ini dan jangan masukkan data apa pun.", feed.Match.Category, feed.Match.Feed)
+	return result
+}
+
+// lexical menjalankan LexicalAnalysis dan mengembalikan skornya.
+func (h *heuristic) lexical(rawURL string) int {
+	h.record("lexical_analysis", rawURL)
+	lex, err := h.tools.LexicalAnalysis(rawURL)
+	if err != nil {
+		return 0
+	}
+	h.findings = append(h.findings, lex.Findings...)
+	return lex.SuspicionScore
+}
+
+func (h *heuristic) record(tool, arg string) {
+	h.checks = append(h.checks, ToolCall{ToolName: tool, Arguments: map[string]string{"url": arg}})
+}
+
+func (h *heuristic) result() *URLScanResult {
+	result := &URLScanResult{
+		Status:    "COMPLETED",
+		Heuristic: true,
+		ToolCalls: h.checks,
+		Reasoning: fmt.Sprintf("heuristic score %d", h.score),
+	}
+	if len(h.findings) > 0 {
+		result.Reasoning += ": " + strings.Join(h.findings, ", ")
+	}
+	return result
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
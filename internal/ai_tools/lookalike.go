@@ -0,0 +1,357 @@
+package aitools
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// protectedDomain adalah domain resmi yang sering ditiru, beserta label merek-nya
+// (label sebelum suffix publik, misal "bankmandiri" untuk bankmandiri.co.id).
+type protectedDomain struct {
+	domain string
+	brand  string
+	// alternates adalah domain resmi lain milik merek yang sama, misal whatsapp.net dan wa.me
+	// untuk whatsapp.com, agar tidak dianggap tiruan.
+	alternates []string
+}
+
+// owns melaporkan apakah host adalah domain resmi merek ini atau subdomainnya.
+func (p protectedDomain) owns(host string) bool {
+	for _, d := range append([]string{p.domain}, p.alternates...) {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseProtectedDomains membaca daftar domain resmi seperti config.DefaultProtectedBrands.
+// Setiap entri berbentuk "domain" atau "domain|alternatif|...". Entri yang bukan domain valid dilewati.
+func parseProtectedDomains(domains []string) []protectedDomain {
+	var protected []protectedDomain
+	for _, entry := range domains {
+		names := strings.Split(strings.ToLower(entry), "|")
+		d := strings.TrimSpace(names[0])
+		etld1, err := publicsuffix.EffectiveTLDPlusOne(d)
+		if err != nil {
+			continue
+		}
+		brand, _, _ := strings.Cut(etld1, ".")
+		p := protectedDomain{domain: d, brand: brand}
+		for _, alt := range names[1:] {
+			if alt = strings.TrimSpace(alt); alt != "" {
+				p.alternates = append(p.alternates, alt)
+			}
+		}
+		protected = append(protected, p)
+	}
+	return protected
+}
+
+// scripts adalah aksara yang dibedakan saat mencari label dengan campuran aksara.
+var scripts = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"Latin", unicode.Latin},
+	{"Cyrillic", unicode.Cyrillic},
+	{"Greek", unicode.Greek},
+	{"Armenian", unicode.Armenian},
+	{"Hebrew", unicode.Hebrew},
+	{"Arabic", unicode.Arabic},
+	{"Thai", unicode.Thai},
+	{"Han", unicode.Han},
+}
+
+// confusables memetakan karakter yang mirip huruf Latin ke huruf aslinya. Daftar ini tidak lengkap,
+// hanya karakter yang sering dipakai di domain tiruan.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'һ': 'h', 'і': 'i', 'ї': 'i', 'ј': 'j', 'к': 'k', 'ӏ': 'l',
+	'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c', 'ѕ': 's', 'т': 't', 'у': 'y', 'х': 'x', 'ԁ': 'd',
+	'ԛ': 'q', 'ԝ': 'w',
+	// Greek
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't',
+	'υ': 'u', 'χ': 'x',
+	// Latin dengan diakritik atau bentuk lain
+	'à': 'a', 'á': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a', 'å': 'a', 'ç': 'c', 'è': 'e', 'é': 'e', 'ê': 'e',
+	'ë': 'e', 'ì': 'i', 'í': 'i', 'î': 'i', 'ï': 'i', 'ı': 'i', 'ñ': 'n', 'ò': 'o', 'ó': 'o', 'ô': 'o',
+	'õ': 'o', 'ö': 'o', 'ø': 'o', 'ù': 'u', 'ú': 'u', 'û': 'u', 'ü': 'u', 'ý': 'y', 'ÿ': 'y', 'ł': 'l',
+	'ś': 's', 'ş': 's', 'ž': 'z', 'ż': 'z', 'ğ': 'g', 'ķ': 'k', 'ņ': 'n', 'ɡ': 'g',
+}
+
+// asciiConfusables adalah rangkaian ASCII yang terlihat seperti huruf lain, misal "rn" seperti "m".
+var asciiConfusables = strings.NewReplacer("rn", "m", "vv", "w", "0", "o", "1", "l", "5", "s", "3", "e")
+
+// skeleton mengubah label ke bentuk "kerangka" agar label yang terlihat sama menjadi identik.
+func skeleton(label string) string {
+	var sb strings.Builder
+	for _, r := range label {
+		if c, ok := confusables[r]; ok {
+			r = c
+		}
+		sb.WriteRune(r)
+	}
+	return asciiConfusables.Replace(sb.String())
+}
+
+// keyboardRows adalah baris keyboard QWERTY untuk menilai salah ketik yang disengaja.
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+// keyboardAdjacent memeriksa apakah dua huruf bersebelahan di keyboard QWERTY,
+// termasuk baris di atas atau di bawahnya.
+func keyboardAdjacent(a, b rune) bool {
+	pos := func(r rune) (int, int) {
+		for row, keys := range keyboardRows {
+			if col := strings.IndexRune(keys, r); col >= 0 {
+				return row, col
+			}
+		}
+		return -1, -1
+	}
+	ra, ca := pos(a)
+	rb, cb := pos(b)
+	if ra < 0 || rb < 0 || (ra == rb && ca == cb) {
+		return false
+	}
+	return abs(ra-rb) <= 1 && abs(ca-cb) <= 1
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// editDistance menghitung jarak Damerau-Levenshtein (optimal string alignment) antara a dan b,
+// sehingga pertukaran dua huruf bersebelahan seperti "bac" dan "bca" dihitung satu.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// substitutionIsAdjacent memeriksa apakah a dan b hanya berbeda satu huruf yang
+// bersebelahan di keyboard, misal "bxa" dan "bca".
+func substitutionIsAdjacent(a, b string) bool {
+	x, y, ok := singleSubstitution(a, b)
+	return ok && keyboardAdjacent(x, y)
+}
+
+// sameRowAdjacent memeriksa apakah dua huruf bersebelahan di baris keyboard yang sama. Lebih
+// ketat daripada keyboardAdjacent karena dipakai untuk merek pendek, misal "g" dan "v" (jago
+// dan java) tidak dihitung.
+func sameRowAdjacent(a, b rune) bool {
+	for _, keys := range keyboardRows {
+		ia, ib := strings.IndexRune(keys, a), strings.IndexRune(keys, b)
+		if ia >= 0 && ib >= 0 {
+			return abs(ia-ib) == 1
+		}
+	}
+	return false
+}
+
+// lookalikeAnalysis mendeteksi serangan homoglyph IDN dan typosquatting terhadap domain resmi.
+// Skor dan temuan ditambahkan ke hasil LexicalAnalysis.
+func (t *Tools) lookalikeAnalysis(hostname string) (score int, findings []string) {
+	ascii, err := idna.Punycode.ToASCII(strings.ToLower(hostname))
+	if err != nil {
+		return 0, nil
+	}
+	unicodeHost, err := idna.Punycode.ToUnicode(ascii)
+	if err != nil {
+		unicodeHost = ascii
+	}
+
+	if strings.HasPrefix(ascii, "xn--") || strings.Contains(ascii, ".xn--") {
+		score += 2
+		findings = append(findings, fmt.Sprintf("punycode_host:%s", unicodeHost))
+	}
+
+	confusable := false
+	for _, label := range strings.Split(unicodeHost, ".") {
+		if mixed := labelScripts(label); len(mixed) > 1 {
+			score += 3
+			findings = append(findings, fmt.Sprintf("mixed_scripts:%s", strings.Join(mixed, "+")))
+		}
+		for _, r := range label {
+			if _, ok := confusables[r]; ok {
+				confusable = true
+			}
+		}
+	}
+	if confusable {
+		score += 2
+		findings = append(findings, "confusable_chars:true")
+	}
+
+	brandScore, brandFindings := t.brandAnalysis(ascii, unicodeHost)
+	return score + brandScore, append(findings, brandFindings...)
+}
+
+// brandAnalysis membandingkan host dengan domain resmi. Host yang memang domain resmi atau
+// subdomainnya dilewati. Hanya temuan terkuat per domain resmi yang dilaporkan.
+func (t *Tools) brandAnalysis(ascii, unicodeHost string) (score int, findings []string) {
+	for _, p := range t.protected {
+		if p.owns(ascii) {
+			return 0, nil
+		}
+	}
+
+	etld1, err := publicsuffix.EffectiveTLDPlusOne(ascii)
+	if err != nil {
+		return 0, nil
+	}
+	registered, _, _ := strings.Cut(etld1, ".")
+	// Label merek yang ditulis dengan karakter tiruan dibandingkan lewat bentuk unicode-nya.
+	unicodeRegistered := registered
+	if u, err := idna.Punycode.ToUnicode(registered); err == nil {
+		unicodeRegistered = u
+	}
+	tokens := strings.FieldsFunc(unicodeHost, func(r rune) bool { return r == '.' || r == '-' || r == '_' })
+
+	for _, p := range t.protected {
+		switch {
+		case skeleton(unicodeRegistered) == skeleton(p.brand) && unicodeRegistered != p.brand:
+			score += 4
+			findings = append(findings, fmt.Sprintf("homoglyph_of:%s", p.domain))
+
+		case registered == p.brand:
+			// Merek sama dengan suffix lain, misal bca.xyz untuk bca.co.id. Banyak merek punya
+			// domain resmi dengan suffix lain yang belum terdaftar sebagai alternatif, jadi sinyal
+			// ini sengaja lemah dan tidak cukup untuk verdict mencurigakan tanpa sinyal lain.
+			score += 2
+			findings = append(findings, fmt.Sprintf("brand_other_tld:%s", p.domain))
+
+		case len(p.brand) >= 4 && typosquatScore(registered, p.brand) > 0:
+			score += typosquatScore(registered, p.brand)
+			finding := fmt.Sprintf("typosquat_of:%s (distance:%d", p.domain, editDistance(registered, p.brand))
+			if substitutionIsAdjacent(registered, p.brand) {
+				finding += ", keyboard_adjacent"
+			}
+			findings = append(findings, finding+")")
+
+		case containsToken(tokens, p.brand):
+			score += 2
+			findings = append(findings, fmt.Sprintf("brand_in_host:%s", p.domain))
+		}
+	}
+	sort.Strings(findings)
+	return score, findings
+}
+
+// shortBrandLength adalah panjang merek yang dianggap pendek. Satu edit bebas pada merek pendek
+// mengenai terlalu banyak kata biasa (jago dan java, dana dan data, livin dan living).
+const shortBrandLength = 6
+
+// lookalikeChars adalah pasangan karakter ASCII yang mudah tertukar secara visual.
+var lookalikeChars = []string{"il", "ij", "lj", "uv", "gq", "ce", "co", "i1", "a4", "b8", "g9", "t7"}
+
+// typosquatScore menilai apakah label hanya berbeda sedikit dari merek; 0 berarti bukan tiruan.
+// Merek sepanjang shortBrandLength atau lebih menerima satu edit apa pun, dan dua edit untuk
+// merek sepanjang delapan huruf atau lebih. Merek yang lebih pendek hanya menerima satu
+// penggantian karakter yang mirip secara visual, atau (dengan skor lebih rendah) tombol
+// tetangga di baris keyboard yang sama.
+func typosquatScore(label, brand string) int {
+	if label == brand {
+		return 0
+	}
+	if len(brand) < shortBrandLength {
+		a, b, ok := singleSubstitution(label, brand)
+		switch {
+		case !ok:
+			return 0
+		case slices.Contains(lookalikeChars, string([]rune{a, b})) || slices.Contains(lookalikeChars, string([]rune{b, a})):
+			return 3
+		case sameRowAdjacent(a, b):
+			return 2
+		}
+		return 0
+	}
+	limit := 1
+	if len(brand) >= 8 {
+		limit = 2
+	}
+	if editDistance(label, brand) <= limit {
+		return 3
+	}
+	return 0
+}
+
+// singleSubstitution mengembalikan pasangan karakter yang berbeda jika a dan b sama panjang
+// dan hanya berbeda di satu posisi.
+func singleSubstitution(a, b string) (rune, rune, bool) {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) != len(rb) {
+		return 0, 0, false
+	}
+	diff := -1
+	for i := range ra {
+		if ra[i] != rb[i] {
+			if diff >= 0 {
+				return 0, 0, false
+			}
+			diff = i
+		}
+	}
+	if diff < 0 {
+		return 0, 0, false
+	}
+	return ra[diff], rb[diff], true
+}
+
+// containsToken memeriksa apakah merek muncul sebagai bagian utuh host, misal "bca" pada
+// "bca.co.id.verify-akun.com" tetapi tidak pada "abcamera.com".
+func containsToken(tokens []string, brand string) bool {
+	for _, token := range tokens {
+		if token == brand {
+			return true
+		}
+	}
+	return false
+}
+
+// labelScripts mengembalikan aksara yang dipakai huruf-huruf dalam label, sesuai urutan kemunculan.
+func labelScripts(label string) []string {
+	var found []string
+	for _, r := range label {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		for _, s := range scripts {
+			if unicode.Is(s.table, r) {
+				if !containsToken(found, s.name) {
+					found = append(found, s.name)
+				}
+				break
+			}
+		}
+	}
+	return found
+}
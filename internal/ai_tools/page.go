@@ -0,0 +1,324 @@
+package aitools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Batas ringkasan halaman agar hasil tool tetap kecil di prompt Gemini.
+const (
+	maxPageItems    = 10
+	maxTextExcerpt  = 500
+	maxFaviconBytes = 100 << 10
+)
+
+// cardFieldTokens adalah kata utuh pada nama/id input yang menandakan data kartu atau OTP.
+// Dicocokkan per kata agar "pin" tidak mengenai "shipping" dan "card" tidak mengenai "discard".
+var cardFieldTokens = []string{
+	"card", "kartu", "cc", "ccnum", "cardnumber", "cardno", "creditcard", "cvv", "cvv2", "cvc",
+	"expiry", "exp", "expdate", "pin", "otp",
+}
+
+// pageMetaNames adalah meta tag yang disertakan dalam ringkasan.
+var pageMetaNames = []string{"description", "og:title", "og:site_name", "og:url", "og:description", "robots", "generator", "application-name"}
+
+type PageForm struct {
+	Action         string `json:"action"`
+	Method         string `json:"method"`
+	ExternalAction bool   `json:"external_action,omitempty"`
+	Inputs         int    `json:"inputs"`
+	PasswordInputs int    `json:"password_inputs,omitempty"`
+	CardInputs     int    `json:"card_inputs,omitempty"`
+}
+
+type PageAnalysis struct {
+	URL         string `json:"url"`
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type"`
+	// Redirected bernilai true jika URL akhir berbeda dengan URL yang diminta.
+	Redirected          bool              `json:"redirected,omitempty"`
+	Title               string            `json:"title,omitempty"`
+	Meta                map[string]string `json:"meta,omitempty"`
+	Forms               []PageForm        `json:"forms,omitempty"`
+	HasPasswordInput    bool              `json:"has_password_input"`
+	HasCardInput        bool              `json:"has_card_input"`
+	ExternalScriptHosts []string          `json:"external_script_hosts,omitempty"`
+	IframeSources       []string          `json:"iframe_sources,omitempty"`
+	BrandKeywords       []string          `json:"brand_keywords,omitempty"`
+	TextExcerpt         string            `json:"text_excerpt,omitempty"`
+	FaviconURL          string            `json:"favicon_url,omitempty"`
+	// FaviconSHA256 bisa dibandingkan dengan favicon situs resmi untuk mendeteksi tiruan.
+	FaviconSHA256 string `json:"favicon_sha256,omitempty"`
+}
+
+// FetchPageContent mengambil halaman dan mengembalikan ringkasan terstruktur (judul, meta, form,
+// input sensitif, script dan iframe eksternal, merek yang disebut, cuplikan teks dan hash favicon)
+// alih-alih HTML mentah yang terlalu besar untuk prompt.
+func (t *Tools) FetchPageContent(rawURL string) (*PageAnalysis, error) {
+	t.log.Info("getting page Content for", "url", rawURL)
+	resp, err := t.client.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	page := &PageAnalysis{
+		URL:         resp.Request.URL.String(),
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Redirected:  resp.Request.URL.String() != rawURL,
+	}
+	if !strings.Contains(page.ContentType, "html") {
+		return page, nil
+	}
+
+	doc, err := html.Parse(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca HTML: %w", err)
+	}
+	a := &pageAnalyzer{page: page, base: resp.Request.URL}
+	a.walk(doc)
+	page.TextExcerpt = a.excerpt()
+	page.BrandKeywords = t.brandKeywords(page.Title + " " + a.text.String())
+	t.hashFavicon(page, a.favicon)
+	return page, nil
+}
+
+// pageAnalyzer mengumpulkan sinyal dari pohon HTML dalam satu kali jalan.
+type pageAnalyzer struct {
+	page    *PageAnalysis
+	base    *url.URL
+	text    strings.Builder
+	form    *PageForm
+	favicon string
+}
+
+func (a *pageAnalyzer) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if a.text.Len() < maxTextExcerpt*4 {
+			a.text.WriteString(n.Data)
+			a.text.WriteByte(' ')
+		}
+	case html.ElementNode:
+		switch n.DataAtom {
+		case atom.Script:
+			if src := attr(n, "src"); src != "" {
+				if host := a.externalHost(src); host != "" && len(a.page.ExternalScriptHosts) < maxPageItems && !slices.Contains(a.page.ExternalScriptHosts, host) {
+					a.page.ExternalScriptHosts = append(a.page.ExternalScriptHosts, host)
+				}
+			}
+			return
+		case atom.Style, atom.Noscript, atom.Template:
+			return
+		case atom.Title:
+			if a.page.Title == "" && n.FirstChild != nil {
+				a.page.Title = strings.TrimSpace(n.FirstChild.Data)
+			}
+			return
+		case atom.Meta:
+			a.meta(n)
+		case atom.Link:
+			rel := strings.ToLower(attr(n, "rel"))
+			if a.favicon == "" && strings.Contains(rel, "icon") {
+				// Favicon data: URI dilewati; yang diambil hanya favicon http(s).
+				if u, err := a.base.Parse(strings.TrimSpace(attr(n, "href"))); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+					a.favicon = u.String()
+				}
+			}
+		case atom.Iframe, atom.Frame:
+			if src := a.resolve(attr(n, "src")); src != "" && len(a.page.IframeSources) < maxPageItems {
+				a.page.IframeSources = append(a.page.IframeSources, src)
+			}
+		case atom.Form:
+			a.startForm(n)
+			defer a.endForm()
+		case atom.Input, atom.Select, atom.Textarea:
+			a.input(n)
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		a.walk(c)
+	}
+}
+
+func (a *pageAnalyzer) meta(n *html.Node) {
+	name := strings.ToLower(attr(n, "name"))
+	if name == "" {
+		name = strings.ToLower(attr(n, "property"))
+	}
+	if equiv := strings.ToLower(attr(n, "http-equiv")); equiv == "refresh" {
+		name = "refresh"
+	}
+	if name != "refresh" && !slices.Contains(pageMetaNames, name) {
+		return
+	}
+	if a.page.Meta == nil {
+		a.page.Meta = make(map[string]string)
+	}
+	a.page.Meta[name] = truncate(attr(n, "content"), 200)
+}
+
+func (a *pageAnalyzer) startForm(n *html.Node) {
+	action := attr(n, "action")
+	form := PageForm{
+		Action: a.resolve(action),
+		Method: strings.ToUpper(attr(n, "method")),
+	}
+	if form.Method == "" {
+		form.Method = "GET"
+	}
+	if form.Action == "" {
+		form.Action = a.base.String()
+	}
+	form.ExternalAction = a.externalHost(form.Action) != ""
+	a.form = &form
+}
+
+func (a *pageAnalyzer) endForm() {
+	if a.form != nil && len(a.page.Forms) < maxPageItems {
+		a.page.Forms = append(a.page.Forms, *a.form)
+	}
+	a.form = nil
+}
+
+func (a *pageAnalyzer) input(n *html.Node) {
+	kind := strings.ToLower(attr(n, "type"))
+	if kind == "hidden" || kind == "submit" || kind == "button" {
+		return
+	}
+	password := kind == "password"
+	card := isCardField(n)
+
+	a.page.HasPasswordInput = a.page.HasPasswordInput || password
+	a.page.HasCardInput = a.page.HasCardInput || card
+	if a.form == nil {
+		return
+	}
+	a.form.Inputs++
+	if password {
+		a.form.PasswordInputs++
+	}
+	if card {
+		a.form.CardInputs++
+	}
+}
+
+// isCardField melaporkan apakah input meminta data kartu atau OTP. Token autocomplete
+// dicocokkan persis (cc-* atau one-time-code), sedangkan nama dan id dipecah per kata.
+func isCardField(n *html.Node) bool {
+	for _, token := range strings.Fields(strings.ToLower(attr(n, "autocomplete"))) {
+		if strings.HasPrefix(token, "cc-") || token == "one-time-code" {
+			return true
+		}
+	}
+	words := strings.FieldsFunc(strings.ToLower(attr(n, "name")+" "+attr(n, "id")), func(r rune) bool {
+		return strings.ContainsRune("-_[]. ", r)
+	})
+	return slices.ContainsFunc(words, func(w string) bool { return slices.Contains(cardFieldTokens, w) })
+}
+
+// resolve mengubah link relatif menjadi absolut terhadap URL halaman.
+func (a *pageAnalyzer) resolve(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+	u, err := a.base.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return truncate(u.String(), 200)
+}
+
+// externalHost mengembalikan host link jika domain terdaftarnya berbeda dengan halaman.
+func (a *pageAnalyzer) externalHost(ref string) string {
+	u, err := a.base.Parse(ref)
+	if err != nil || u.Hostname() == "" {
+		return ""
+	}
+	if registeredDomain(u.Hostname()) == registeredDomain(a.base.Hostname()) {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// excerpt merapikan spasi teks yang terlihat dan memotongnya.
+func (a *pageAnalyzer) excerpt() string {
+	return truncate(strings.Join(strings.Fields(a.text.String()), " "), maxTextExcerpt)
+}
+
+// brandKeywords mencari merek yang dilindungi (lihat NewTools) yang disebut di judul atau teks halaman.
+func (t *Tools) brandKeywords(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	// Merek panjang juga dicari tanpa spasi, misal "Bank Mandiri" untuk bankmandiri.
+	joined := strings.Join(words, "")
+	var found []string
+	for _, p := range t.protected {
+		mentioned := slices.Contains(words, p.brand) || (len(p.brand) >= 6 && strings.Contains(joined, p.brand))
+		if mentioned && !slices.Contains(found, p.brand) {
+			found = append(found, p.brand)
+		}
+	}
+	return found
+}
+
+// hashFavicon mengunduh favicon (dari <link rel="icon"> atau /favicon.ico) dan mencatat hash-nya.
+// Kegagalan diabaikan karena favicon hanya sinyal tambahan.
+func (t *Tools) hashFavicon(page *PageAnalysis, favicon string) {
+	if favicon == "" {
+		base, err := url.Parse(page.URL)
+		if err != nil {
+			return
+		}
+		favicon = base.ResolveReference(&url.URL{Path: "/favicon.ico"}).String()
+	}
+
+	resp, err := t.client.Get(favicon)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxFaviconBytes))
+	if err != nil || len(body) == 0 {
+		return
+	}
+	hash := sha256.Sum256(body)
+	page.FaviconURL = favicon
+	page.FaviconSHA256 = hex.EncodeToString(hash[:])
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	// Potong di batas rune agar teks UTF-8 tetap valid.
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,162 @@
+package aitools
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// maxRedirectHops membatasi panjang rantai redirect yang diikuti TraceRedirects.
+const maxRedirectHops = 10
+
+// maxRedirectBody adalah jumlah byte halaman yang dibaca untuk mencari meta refresh atau redirect JavaScript.
+const maxRedirectBody = 256 << 10
+
+// metaRefreshRe menangkap target <meta http-equiv="refresh" content="0; url=...">.
+var metaRefreshRe = regexp.MustCompile(`(?is)<meta[^>]+http-equiv\s*=\s*["']?refresh["']?[^>]*content\s*=\s*["']\s*\d*\s*;?\s*url\s*=\s*['"]?([^"'>\s]+)`)
+
+// scriptRe menangkap isi tag <script> inline; redirect JavaScript hanya dicari di sana agar
+// atribut HTML seperti data-location="..." tidak ikut terbaca.
+var scriptRe = regexp.MustCompile(`(?is)<script\b[^>]*>(.*?)</script>`)
+
+// jsRedirectRe menangkap redirect JavaScript sederhana seperti window.location = "...",
+// location.href = "..." atau location.replace('...'). Awalan batas mencegah geolocation,
+// relocation atau obj.location ikut cocok. Grup 1 berisi var/let/const jika yang cocok adalah
+// deklarasi variabel bernama location, yang bukan redirect; grup 2 adalah URL tujuan.
+var jsRedirectRe = regexp.MustCompile(`(?i)(?:^|[^\w.$-])(?:(?:window|document|top|self)\.location(?:\.href)?\s*(?:=\s*|\.(?:replace|assign)\s*\(\s*)|location\.href\s*=\s*|location\.(?:replace|assign)\s*\(\s*|(?:(var|let|const)\s+)?location\s*=\s*)["']([^"']+)["']`)
+
+type RedirectHop struct {
+	URL        string `json:"url"`
+	Host       string `json:"host"`
+	StatusCode int    `json:"status_code,omitempty"`
+	// Via adalah cara hop ini mengarah ke hop berikutnya: "http", "meta_refresh" atau "javascript".
+	Via         string `json:"via,omitempty"`
+	DurationMS  int64  `json:"duration_ms"`
+	CrossDomain bool   `json:"cross_domain,omitempty"`
+}
+
+type RedirectTraceResult struct {
+	Hops            []RedirectHop `json:"hops"`
+	FinalURL        string        `json:"final_url"`
+	CrossDomainHops int           `json:"cross_domain_hops"`
+	Loop            bool          `json:"loop,omitempty"`
+	// LimitReached bernilai true jika rantai masih berlanjut setelah maxRedirectHops.
+	LimitReached bool   `json:"limit_reached,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// TraceRedirects mengikuti seluruh rantai redirect (HTTP 3xx, meta refresh dan JavaScript
+// location sederhana) dan mencatat setiap hop. Kegagalan di tengah rantai tidak dianggap error:
+// hop yang sudah didapat tetap dikembalikan dengan field Error terisi.
+func (t *Tools) TraceRedirects(rawURL string) (*RedirectTraceResult, error) {
+	t.log.Info("tracing redirects for", "url", rawURL)
+	current, err := url.Parse(rawURL)
+	if err != nil || current.Host == "" {
+		return nil, fmt.Errorf("URL tidak valid: %s", rawURL)
+	}
+
+	result := &RedirectTraceResult{}
+	visited := make(map[string]bool)
+	for len(result.Hops) < maxRedirectHops {
+		hop := RedirectHop{URL: current.String(), Host: current.Hostname()}
+		if n := len(result.Hops); n > 0 && registeredDomain(result.Hops[n-1].Host) != registeredDomain(hop.Host) {
+			hop.CrossDomain = true
+			result.CrossDomainHops++
+		}
+		visited[hop.URL] = true
+
+		start := time.Now()
+		next, status, via, err := t.nextHop(current)
+		hop.DurationMS = time.Since(start).Milliseconds()
+		hop.StatusCode = status
+		hop.Via = via
+		result.Hops = append(result.Hops, hop)
+		result.FinalURL = hop.URL
+
+		if err != nil {
+			result.Error = err.Error()
+			return result, nil
+		}
+		if next == nil {
+			return result, nil
+		}
+		next.Fragment = ""
+		if via != "http" && next.String() == hop.URL {
+			// Halaman yang memuat ulang dirinya sendiri lewat meta refresh atau JavaScript bukan redirect.
+			result.Hops[len(result.Hops)-1].Via = ""
+			return result, nil
+		}
+		if visited[next.String()] {
+			result.Loop = true
+			return result, nil
+		}
+		current = next
+	}
+	result.LimitReached = true
+	return result, nil
+}
+
+// nextHop meminta satu URL tanpa mengikuti redirect dan mengembalikan tujuan berikutnya,
+// atau nil jika halaman ini adalah tujuan akhir.
+func (t *Tools) nextHop(current *url.URL) (next *url.URL, status int, via string, err error) {
+	resp, err := t.redirectClient.Get(current.String())
+	if err != nil {
+		return nil, 0, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		loc, err := resp.Location()
+		if err != nil {
+			return nil, resp.StatusCode, "", err
+		}
+		return loc, resp.StatusCode, "http", nil
+	}
+
+	if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		return nil, resp.StatusCode, "", nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRedirectBody))
+	if err != nil {
+		return nil, resp.StatusCode, "", err
+	}
+	if m := metaRefreshRe.FindSubmatch(body); m != nil {
+		if target := redirectTarget(current, m[1]); target != nil {
+			return target, resp.StatusCode, "meta_refresh", nil
+		}
+	}
+	for _, script := range scriptRe.FindAllSubmatch(body, -1) {
+		for _, m := range jsRedirectRe.FindAllSubmatch(script[1], -1) {
+			if len(m[1]) > 0 {
+				continue
+			}
+			if target := redirectTarget(current, m[2]); target != nil {
+				return target, resp.StatusCode, "javascript", nil
+			}
+		}
+	}
+	return nil, resp.StatusCode, "", nil
+}
+
+// redirectTarget mengubah target redirect dari halaman menjadi URL absolut http(s), atau nil.
+func redirectTarget(current *url.URL, raw []byte) *url.URL {
+	target, err := current.Parse(strings.TrimSpace(string(raw)))
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") {
+		return nil
+	}
+	return target
+}
+
+// registeredDomain mengembalikan domain terdaftar dari host, atau host itu sendiri
+// jika tidak bisa ditentukan (misal alamat IP).
+func registeredDomain(host string) string {
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return domain
+	}
+	return host
+}
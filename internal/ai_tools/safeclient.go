@@ -0,0 +1,142 @@
+package aitools
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Batas untuk request ke URL yang dikirim orang lain di grup.
+const (
+	safeDialTimeout     = 5 * time.Second
+	safeRequestTimeout  = 20 * time.Second
+	safeMaxBodySize     = 2 << 20
+	safeMaxRedirects    = 10
+	safeResponseTimeout = 10 * time.Second
+)
+
+// ErrBlockedAddress dikembalikan jika request menuju alamat yang tidak boleh diakses bot,
+// misal jaringan internal atau endpoint metadata cloud.
+var ErrBlockedAddress = errors.New("alamat tujuan diblokir")
+
+// safePorts adalah port tujuan yang diizinkan.
+var safePorts = []int{80, 443, 8080, 8443}
+
+// blockedPrefixes adalah rentang alamat yang tidak boleh dihubungi, di luar yang sudah ditolak
+// oleh pemeriksaan netip (loopback, private, link-local termasuk metadata 169.254.169.254,
+// multicast dan unspecified).
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),        // "jaringan ini"
+	netip.MustParsePrefix("100.64.0.0/10"),    // CGNAT, sering dipakai VPN internal
+	netip.MustParsePrefix("192.0.0.0/24"),     // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),    // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),      // reserved dan broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),     // NAT64, bisa membungkus alamat IPv4 internal
+	netip.MustParsePrefix("64:ff9b:1::/48"),   // NAT64 lokal
+	netip.MustParsePrefix("2002::/16"),        // 6to4, bisa membungkus alamat IPv4 internal
+	netip.MustParsePrefix("fec0::/10"),        // site-local lama
+	netip.MustParsePrefix("100::/64"),         // discard-only
+	netip.MustParsePrefix("2001:db8::/32"),    // dokumentasi
+	netip.MustParsePrefix("fd00:ec2::/32"),    // metadata AWS lewat IPv6
+	netip.MustParsePrefix("168.63.129.16/32"), // host Azure
+}
+
+// isBlockedIP memeriksa apakah alamat termasuk jaringan internal atau rentang khusus.
+func isBlockedIP(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// safeControl dijalankan tepat sebelum socket tersambung, setelah DNS di-resolve. Karena yang
+// diperiksa adalah alamat yang benar-benar dihubungi, DNS rebinding tidak bisa melewatinya.
+func safeControl(network, address string, _ syscall.RawConn) error {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || !slices.Contains(safePorts, port) {
+		return fmt.Errorf("%w: port %s tidak diizinkan", ErrBlockedAddress, portStr)
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %s bukan alamat IP", ErrBlockedAddress, host)
+	}
+	if isBlockedIP(addr) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, addr)
+	}
+	return nil
+}
+
+// safeTransport menolak skema selain http/https dan membatasi ukuran body respons.
+type safeTransport struct {
+	base *http.Transport
+}
+
+func (t *safeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("%w: skema %q tidak diizinkan", ErrBlockedAddress, req.URL.Scheme)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(resp.Body, safeMaxBodySize), resp.Body}
+	return resp, nil
+}
+
+// newSafeClient membuat http.Client untuk mengambil URL yang tidak dipercaya: alamat internal,
+// port dan skema selain yang diizinkan ditolak, setiap tahap punya timeout dan body dibatasi.
+// Jika followRedirects false, respons 3xx dikembalikan apa adanya.
+func newSafeClient(followRedirects bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: safeDialTimeout,
+		Control: safeControl,
+	}
+	transport := &http.Transport{
+		// Proxy sengaja tidak dipakai: pemeriksaan alamat hanya berlaku untuk koneksi langsung.
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   safeDialTimeout,
+		ResponseHeaderTimeout: safeResponseTimeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	client := &http.Client{
+		Transport: &safeTransport{base: transport},
+		Timeout:   safeRequestTimeout,
+	}
+	if followRedirects {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) >= safeMaxRedirects {
+				return fmt.Errorf("terlalu banyak redirect (%d)", len(via))
+			}
+			return nil
+		}
+	} else {
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return client
+}
@@ -0,0 +1,141 @@
+package aitools
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// freeCAIssuers adalah potongan nama penerbit sertifikat gratis dan otomatis. Sertifikat gratis
+// bukan tanda bahaya sendiri, tetapi bersama domain tiruan yang baru terdaftar menjadi sinyal kuat.
+var freeCAIssuers = []string{"let's encrypt", "zerossl", "buypass", "cpanel", "google trust services", "ssl.com free", "cloudflare"}
+
+type TLSCertificateResult struct {
+	Host               string    `json:"host"`
+	TLSVersion         string    `json:"tls_version"`
+	Subject            string    `json:"subject"`
+	Issuer             string    `json:"issuer"`
+	IssuerOrganization string    `json:"issuer_organization,omitempty"`
+	NotBefore          time.Time `json:"not_before"`
+	NotAfter           time.Time `json:"not_after"`
+	// AgeDays adalah umur sertifikat sejak diterbitkan; sertifikat berumur beberapa hari
+	// pada domain tiruan adalah ciri khas phishing.
+	AgeDays          int      `json:"age_days"`
+	DaysUntilExpiry  int      `json:"days_until_expiry"`
+	ValidityDays     int      `json:"validity_days"`
+	Expired          bool     `json:"expired,omitempty"`
+	SANs             []string `json:"san"`
+	SelfSigned       bool     `json:"self_signed"`
+	HostnameMismatch bool     `json:"hostname_mismatch"`
+	FreeCA           bool     `json:"free_ca"`
+	ChainVerified    bool     `json:"chain_verified"`
+	VerifyError      string   `json:"verify_error,omitempty"`
+}
+
+// InspectTLSCertificate membuka koneksi TLS ke host URL (port 443 jika tidak disebut) dan
+// melaporkan sertifikatnya. Sertifikat yang tidak valid tetap dilaporkan, karena justru itu
+// yang ingin dilihat; koneksi memakai pemeriksaan alamat yang sama dengan client HTTP.
+func (t *Tools) InspectTLSCertificate(rawURL string) (*TLSCertificateResult, error) {
+	t.log.Info("inspecting tls certificate for", "url", rawURL)
+	host, port, err := tlsTarget(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{InsecureSkipVerify: true}
+	if net.ParseIP(host) == nil {
+		config.ServerName = host
+	}
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: safeDialTimeout, Control: safeControl},
+		Config:    config,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), safeRequestTimeout)
+	defer cancel()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil, fmt.Errorf("server %s tidak mengirim sertifikat", host)
+	}
+	leaf := state.PeerCertificates[0]
+	now := time.Now()
+
+	result := &TLSCertificateResult{
+		Host:               host,
+		TLSVersion:         tls.VersionName(state.Version),
+		Subject:            leaf.Subject.CommonName,
+		Issuer:             leaf.Issuer.CommonName,
+		IssuerOrganization: strings.Join(leaf.Issuer.Organization, ", "),
+		NotBefore:          leaf.NotBefore,
+		NotAfter:           leaf.NotAfter,
+		AgeDays:            int(now.Sub(leaf.NotBefore).Hours() / 24),
+		DaysUntilExpiry:    int(leaf.NotAfter.Sub(now).Hours() / 24),
+		ValidityDays:       int(leaf.NotAfter.Sub(leaf.NotBefore).Hours() / 24),
+		Expired:            now.After(leaf.NotAfter),
+		SANs:               leaf.DNSNames,
+		SelfSigned:         isSelfSigned(leaf),
+		HostnameMismatch:   leaf.VerifyHostname(host) != nil,
+	}
+	for _, ip := range leaf.IPAddresses {
+		result.SANs = append(result.SANs, ip.String())
+	}
+	if len(result.SANs) > maxPageItems*2 {
+		// Sertifikat CDN bisa memuat ratusan SAN; sisanya tidak menambah sinyal.
+		result.SANs = append(result.SANs[:maxPageItems*2], fmt.Sprintf("... (%d lagi)", len(result.SANs)-maxPageItems*2))
+	}
+
+	issuer := strings.ToLower(result.Issuer + " " + result.IssuerOrganization)
+	for _, free := range freeCAIssuers {
+		if strings.Contains(issuer, free) {
+			result.FreeCA = true
+			break
+		}
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := leaf.Verify(x509.VerifyOptions{DNSName: host, Intermediates: intermediates}); err != nil {
+		result.VerifyError = err.Error()
+	} else {
+		result.ChainVerified = true
+	}
+	return result, nil
+}
+
+// tlsTarget mengambil host dan port dari URL atau host biasa. Port 443 dipakai jika URL
+// tidak menyebut port atau berskema http.
+func tlsTarget(rawURL string) (host, port string, err error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return "", "", fmt.Errorf("URL tidak valid: %s", rawURL)
+	}
+	port = u.Port()
+	if port == "" || u.Scheme == "http" {
+		port = "443"
+	}
+	return strings.ToLower(u.Hostname()), port, nil
+}
+
+// isSelfSigned memeriksa apakah sertifikat ditandatangani oleh kuncinya sendiri.
+func isSelfSigned(cert *x509.Certificate) bool {
+	if cert.Subject.String() != cert.Issuer.String() {
+		return false
+	}
+	// CheckSignatureFrom menolak sertifikat non-CA, padahal banyak sertifikat self-signed tidak ditandai CA.
+	return cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
@@ -9,10 +9,15 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/Satr10/wa-userbot/internal/logger"
+	"github.com/Satr10/wa-userbot/internal/threatintel"
+	"github.com/Satr10/wa-userbot/internal/urlcanon"
 	"github.com/likexian/whois"
+	"golang.org/x/net/publicsuffix"
 )
 
 type Tools struct {
@@ -20,21 +25,25 @@ type Tools struct {
 	client             *http.Client
 	redirectClient     *http.Client
 	safeBrowsingApiKey string
+	feeds              *threatintel.Manager
+	protected          []protectedDomain
 }
 
-func NewTools(safeBrowsingApiKey string) *Tools {
+// NewTools membuat Tools. protectedDomains adalah domain resmi (bank, e-wallet, marketplace)
+// yang tiruannya dideteksi oleh LexicalAnalysis.
+func NewTools(safeBrowsingApiKey string, feeds *threatintel.Manager, protectedDomains []string) *Tools {
 	if safeBrowsingApiKey == "" {
 		panic("no safe safeBrowsingApiKey")
 	}
 	return &Tools{
-		client: &http.Client{},
-		redirectClient: &http.Client{
-			CheckRedirect: func(req *http.Request, via []*http.Request) error {
-				return http.ErrUseLastResponse
-			},
-		},
+		// Semua tool memakai client yang sama-sama menolak alamat internal, karena URL-nya
+		// berasal dari pesan orang lain.
+		client:             newSafeClient(true),
+		redirectClient:     newSafeClient(false),
 		log:                logger.Get(),
 		safeBrowsingApiKey: safeBrowsingApiKey,
+		feeds:              feeds,
+		protected:          parseProtectedDomains(protectedDomains),
 	}
 }
 
@@ -69,6 +78,50 @@ func (t *Tools) GetWhoisData(url string) (string, error) {
 	return result, nil
 }
 
+// whoisCreatedRe menangkap tanggal pendaftaran dari berbagai format WHOIS registrar dan registry,
+// misal "Creation Date: 2024-05-01T10:00:00Z" atau "Created On: 01-May-2024".
+var whoisCreatedRe = regexp.MustCompile(`(?im)^\s*(?:creation date|created(?: on| date)?|registered(?: on)?|registration (?:date|time)|domain registration date)\s*:\s*(.+?)\s*$`)
+
+// whoisDateLayouts adalah format tanggal yang umum dipakai server WHOIS.
+var whoisDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"02-Jan-2006",
+	"2006.01.02",
+	"2006/01/02",
+	"02.01.2006",
+}
+
+// GetDomainCreated mengambil tanggal pendaftaran domain dari data WHOIS.
+// Host diubah dulu ke domain terdaftar (misal login.bank.co.id menjadi bank.co.id).
+func (t *Tools) GetDomainCreated(host string) (time.Time, error) {
+	domain, err := publicsuffix.EffectiveTLDPlusOne(strings.TrimSuffix(host, "."))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("domain %q tidak valid: %w", host, err)
+	}
+	raw, err := t.GetWhoisData(domain)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	for _, m := range whoisCreatedRe.FindAllStringSubmatch(raw, -1) {
+		value := m[1]
+		// Sebagian server menambahkan jam atau zona setelah tanggal, jadi cukup cocokkan awalannya.
+		for _, layout := range whoisDateLayouts {
+			if len(value) < len(layout) {
+				continue
+			}
+			if created, err := time.Parse(layout, value[:len(layout)]); err == nil {
+				return created, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("tanggal pendaftaran %s tidak ditemukan di data WHOIS", domain)
+}
+
 // Tambahkan fungsi mock lain yang Anda perlukan di sini
 func (t *Tools) CheckGoogleSafeBrowsing(u string) (string, error) {
 	t.log.Info("Checking GSB for", "url", u)
@@ -135,20 +188,30 @@ func (t *Tools) CheckGoogleSafeBrowsing(u string) (string, error) {
 	return string(respBody), nil
 }
 
-func (t *Tools) FetchPageContent(url string) (string, error) {
-	t.log.Info("getting page Content for", "url", url)
-	resp, err := t.client.Get(url)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
+type ThreatFeedResult struct {
+	Listed bool               `json:"listed"`
+	Match  *threatintel.Match `json:"match,omitempty"`
+	// Indicators adalah jumlah indikator yang sedang dimuat, agar model tahu hasil negatif berarti apa.
+	Indicators int `json:"indicators_loaded"`
+}
 
-	body, err := io.ReadAll(resp.Body)
+// CheckThreatFeeds mencocokkan URL dengan feed phishing/malware lokal tanpa request jaringan.
+func (t *Tools) CheckThreatFeeds(rawURL string) (*ThreatFeedResult, error) {
+	t.log.Info("checking threat feeds for", "url", rawURL)
+	if t.feeds == nil {
+		return nil, fmt.Errorf("threat feed tidak dikonfigurasi")
+	}
+	canonical, err := urlcanon.Canonicalize(rawURL)
 	if err != nil {
-		return "", nil
+		return nil, err
 	}
 
-	return string(body), nil
+	result := &ThreatFeedResult{Indicators: t.feeds.Indicators()}
+	if match, ok := t.feeds.Match(canonical); ok {
+		result.Listed = true
+		result.Match = &match
+	}
+	return result, nil
 }
 
 type LexicalAnalysisResult struct {
@@ -220,6 +283,11 @@ func (t *Tools) LexicalAnalysis(rawURL string) (*LexicalAnalysisResult, error) {
 		findings = append(findings, fmt.Sprintf("path_slash_count:%d", slashCount)) // Laporan berbasis data
 	}
 
+	// 9. Cek domain tiruan: homoglyph IDN dan typosquatting merek yang dilindungi
+	lookalikeScore, lookalikeFindings := t.lookalikeAnalysis(hostname)
+	suspicionScore += lookalikeScore
+	findings = append(findings, lookalikeFindings...)
+
 	return &LexicalAnalysisResult{
 		SuspicionScore: suspicionScore,
 		Findings:       findings,
@@ -6,9 +6,13 @@ import (
 	"os"
 	"time"
 
+	"github.com/Satr10/wa-userbot/internal/afk"
 	"github.com/Satr10/wa-userbot/internal/commands"
 	"github.com/Satr10/wa-userbot/internal/config"
+	"github.com/Satr10/wa-userbot/internal/domainlist"
 	"github.com/Satr10/wa-userbot/internal/permissions"
+	"github.com/Satr10/wa-userbot/internal/threatintel"
+	"github.com/Satr10/wa-userbot/internal/urlcache"
 	_ "github.com/lib/pq"
 	"github.com/mdp/qrterminal/v3"
 	"go.mau.fi/whatsmeow"
@@ -25,7 +29,7 @@ type Bot struct {
 	perm       *permissions.Manager
 }
 
-func NewBot(logger waLog.Logger, config config.Config, permManager *permissions.Manager) (newBot *Bot, err error) {
+func NewBot(logger waLog.Logger, config config.Config, permManager *permissions.Manager, afkManager *afk.Manager, urlCache *urlcache.Cache, domains *domainlist.Manager, feeds *threatintel.Manager) (newBot *Bot, err error) {
 	dbLog := waLog.Stdout("Database", "DEBUG", true)
 	ctx := context.Background()
 	container, err := sqlstore.New(ctx, "postgres", config.PostgressURI, dbLog)
@@ -68,7 +72,7 @@ func NewBot(logger waLog.Logger, config config.Config, permManager *permissions.
 			return nil, err
 		}
 	}
-	cmdHandler, err := commands.NewHandler(client, logger, config, permManager)
+	cmdHandler, err := commands.NewHandler(client, logger, config, permManager, afkManager, urlCache, domains, feeds)
 	if err != nil {
 		return nil, err
 	}
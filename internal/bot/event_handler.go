@@ -16,6 +16,9 @@ func (b *Bot) eventHandler(evt any) {
 		// logger.Info("Message received", "message", v.Message)
 		b.cmdHandler.HandleEvent(v)
 
+	case *events.GroupInfo:
+		b.cmdHandler.HandleGroupInfo(v)
+
 	case *events.Receipt:
 
 	case *events.Presence:
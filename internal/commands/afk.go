@@ -0,0 +1,420 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Satr10/wa-userbot/internal/afk"
+	"github.com/Satr10/wa-userbot/internal/durations"
+	"github.com/Satr10/wa-userbot/internal/permissions"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// AFKHandler menangani logika untuk membalas pesan secara otomatis saat owner AFK,
+// baik karena jadwal maupun AFK manual.
+func (h *Handler) AFKHandler(evt *events.Message, msgText string) {
+	// Pesan keluar dari owner tidak dibalas; AFK manual sudah diakhiri oleh HandleEvent.
+	if evt.Info.IsFromMe {
+		return
+	}
+
+	// Periksa apakah saat ini dalam periode AFK.
+	now := time.Now()
+	status := h.afk.Status(now)
+	if !status.Active {
+		return
+	}
+
+	// Tentukan apakah bot harus membalas:
+	// - Selalu balas di Direct Message (DM).
+	// - Di grup, hanya balas jika di-mention.
+	isDM := !evt.Info.IsGroup
+	isMentioned := evt.Info.IsGroup && h.isBotMentioned(evt)
+
+	if !isDM && !isMentioned {
+		return
+	}
+
+	// Kontak VIP melewati daftar ignore; selain itu chat atau pengirim yang di-ignore tidak pernah dibalas.
+	senderKeys := h.identity.Keys(context.Background(), evt.Info.Sender)
+	isVIP := h.perm.InAFKList(permissions.AFKVIP, senderKeys...)
+	if !isVIP && h.perm.InAFKList(permissions.AFKIgnore, append(senderKeys, evt.Info.Chat.String())...) {
+		h.logger.Debugf("Chat %s or sender %s is on the AFK ignore list, skipping", evt.Info.Chat, evt.Info.Sender)
+		return
+	}
+
+	// Selama AFK manual, catat pesan untuk rangkuman saat owner kembali.
+	if status.Manual != nil {
+		err := h.afk.RecordMissed(context.Background(), afk.MissedMessage{
+			ChatID:     evt.Info.Chat.String(),
+			SenderID:   evt.Info.Sender.ToNonAD().String(),
+			SenderName: evt.Info.PushName,
+			MessageID:  evt.Info.ID,
+			Text:       truncateText(msgText, maxDigestText),
+			Time:       evt.Info.Timestamp,
+			Mention:    isMentioned,
+		})
+		if err != nil {
+			h.logger.Errorf("error recording missed message, err: %v", err)
+		}
+	}
+
+	// Pesan VIP diteruskan lewat jalur darurat sebagai ganti balasan otomatis.
+	if isVIP && h.notifyVIP(context.Background(), evt, msgText, status) {
+		return
+	}
+
+	// Jangan membalas chat yang sudah menerima pesan AFK di periode ini.
+	notify, err := h.afk.ClaimNotice(context.Background(), evt.Info.Chat.String(), now)
+	if err != nil {
+		h.logger.Errorf("error saving afk notice, err: %v", err)
+	}
+	if !notify {
+		h.logger.Debugf("Chat %s already received an AFK notice, skipping", evt.Info.Chat)
+		return
+	}
+
+	// Jika semua kondisi terpenuhi, kirim pesan AFK.
+	h.sendAFKMessage(evt, status)
+}
+
+// endManualAFK mematikan AFK manual (jika aktif) dan mengirim rangkuman pesan yang terlewat ke DM owner.
+// ended bernilai false jika AFK manual tidak aktif atau gagal dimatikan.
+func (h *Handler) endManualAFK(ctx context.Context) (ended bool, err error) {
+	manual, missed, err := h.afk.EndManual(ctx)
+	if err != nil {
+		h.logger.Errorf("error ending manual afk, err: %v", err)
+		return false, err
+	}
+	if manual == nil {
+		return false, nil
+	}
+
+	h.logger.Infof("Owner is back from AFK, %d missed messages", len(missed))
+	if len(h.owners) == 0 {
+		h.logger.Warnf("cannot send afk digest, OWNER_ID is not set or invalid")
+		return true, nil
+	}
+	ownerJID := h.identity.Canonical(ctx, h.owners[0])
+	if _, err := SendTextToJID(ctx, h.client, ownerJID, h.renderAFKDigest(ctx, manual, missed)); err != nil {
+		h.logger.Errorf("error sending afk digest, err: %v", err)
+	}
+	return true, nil
+}
+
+// maxDigestText membatasi panjang cuplikan pesan di rangkuman AFK.
+const maxDigestText = 120
+
+// renderAFKDigest merakit rangkuman pesan yang masuk selama AFK manual, dikelompokkan per chat.
+// WhatsApp tidak punya tautan langsung ke pesan, jadi setiap chat diberi tautan wa.me
+// (untuk DM) atau JID grupnya (untuk mention di grup), dan setiap pesan diberi ID-nya.
+func (h *Handler) renderAFKDigest(ctx context.Context, manual *afk.Manual, missed []afk.MissedMessage) string {
+	loc := h.afk.Location()
+
+	var sb strings.Builder
+	sb.WriteString("📬 *RANGKUMAN SELAMA AFK*\n")
+	sb.WriteString("━━━━━━━━━━━━━━━━━━━━\n")
+	sb.WriteString(fmt.Sprintf("AFK sejak %s (%s)", manual.Since.In(loc).Format("02/01 15:04"), formatElapsed(time.Since(manual.Since))))
+	if manual.Reason != "" {
+		sb.WriteString(fmt.Sprintf("\nAlasan: _%s_", manual.Reason))
+	}
+	sb.WriteString("\n")
+
+	if len(missed) == 0 {
+		sb.WriteString("\n_Tidak ada pesan atau mention yang masuk._")
+		return sb.String()
+	}
+
+	// Kelompokkan per chat dengan urutan kemunculan pertama.
+	var chatOrder []string
+	byChat := make(map[string][]afk.MissedMessage)
+	for _, msg := range missed {
+		if _, seen := byChat[msg.ChatID]; !seen {
+			chatOrder = append(chatOrder, msg.ChatID)
+		}
+		byChat[msg.ChatID] = append(byChat[msg.ChatID], msg)
+	}
+
+	for _, chatID := range chatOrder {
+		msgs := byChat[chatID]
+		chatName := h.displayNameOf(ctx, chatID)
+		sb.WriteString(fmt.Sprintf("\n*%s* (%d pesan)\n", chatName, len(msgs)))
+		if jid, err := types.ParseJID(chatID); err == nil {
+			switch jid.Server {
+			case types.DefaultUserServer:
+				sb.WriteString(fmt.Sprintf("🔗 https://wa.me/%s\n", jid.User))
+			case types.GroupServer:
+				sb.WriteString(fmt.Sprintf("👥 %s\n", jid))
+			}
+		}
+
+		for _, msg := range msgs {
+			sender := msg.SenderName
+			if sender == "" {
+				sender = h.displayNameOf(ctx, msg.SenderID)
+			}
+			text := truncateText(msg.Text, maxDigestText)
+			mention := ""
+			if msg.Mention {
+				mention = " (mention)"
+			}
+			sb.WriteString(fmt.Sprintf("• %s %s%s: %s\n  _ID: %s_\n", msg.Time.In(loc).Format("15:04"), sender, mention, text, msg.MessageID))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// truncateText memotong teks menjadi paling banyak n karakter.
+func truncateText(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
+
+// formatElapsed menampilkan durasi singkat seperti "2j 15m".
+func formatElapsed(d time.Duration) string {
+	d = d.Round(time.Minute)
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if hours == 0 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dj %dm", hours, minutes)
+}
+
+// isBotMentioned memeriksa apakah JID bot ada dalam daftar mention pesan.
+func (h *Handler) isBotMentioned(evt *events.Message) bool {
+	extMsg := evt.Message.ExtendedTextMessage
+	if extMsg == nil || extMsg.ContextInfo == nil {
+		return false
+	}
+
+	for _, mentioned := range extMsg.GetContextInfo().GetMentionedJID() {
+		jid, err := types.ParseJID(mentioned)
+		if err != nil {
+			continue
+		}
+		// Mention di grup bisa memakai LID, IsSelf mencocokkan keduanya.
+		if h.identity.IsSelf(context.Background(), jid) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sendAFKMessage merakit pesan dari template AFK lalu mengirimkannya sebagai balasan.
+func (h *Handler) sendAFKMessage(evt *events.Message, status afk.Status) {
+	now := time.Now().In(h.afk.Location())
+	settings := h.afk.Settings()
+
+	template := settings.Message
+	vars := afk.Vars{
+		Name:   h.ownerName(),
+		Sender: evt.Info.PushName,
+		Until:  formatUntil(status.Until, now),
+	}
+	switch {
+	case status.Manual != nil:
+		template = settings.ManualMessage
+		vars.Reason = status.Manual.Reason
+		vars.Since = status.Manual.Since.In(now.Location()).Format("15:04")
+	case status.Event != nil:
+		template = settings.CalendarMessage
+		vars.Event = status.Event.Summary
+	}
+	rawText := afk.Render(template, vars) + Footer
+
+	textMsg := TextMessage{
+		ctx:    context.Background(),
+		evt:    evt,
+		client: h.client,
+		text:   rawText,
+	}
+
+	if _, err := ReplyToTextMesssage(textMsg); err != nil {
+		h.logger.Errorf("error sending afk message, err: %s", err)
+	}
+}
+
+// ownerName mengembalikan nama owner untuk placeholder {name}: AFK_NAME dari config,
+// atau push name akun yang sedang login.
+func (h *Handler) ownerName() string {
+	if h.cfg.AFKName != "" {
+		return h.cfg.AFKName
+	}
+	return h.client.Store.PushName
+}
+
+// longDayNames adalah nama hari dalam bahasa Indonesia, diindeks dengan time.Weekday.
+var longDayNames = [...]string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+
+// formatUntil menampilkan waktu berakhirnya AFK relatif terhadap now, misal "07:00" atau "besok 07:00".
+func formatUntil(until, now time.Time) string {
+	if until.IsZero() {
+		return "nanti"
+	}
+	clock := until.Format("15:04")
+	uy, um, ud := until.Date()
+	ny, nm, nd := now.Date()
+	switch {
+	case uy == ny && um == nm && ud == nd:
+		return clock
+	case until.Sub(now) < 48*time.Hour && until.Day() == now.AddDate(0, 0, 1).Day():
+		return "besok " + clock
+	case clock == "00:00":
+		// Akhir event seharian (misal cuti) cukup ditampilkan harinya: "Senin 13/10".
+		return fmt.Sprintf("%s %s", longDayNames[until.Weekday()], until.Format("02/01"))
+	default:
+		return fmt.Sprintf("%s %s", until.Format("02/01/2006"), clock)
+	}
+}
+
+// AFKCommand menyalakan AFK manual (.afk <alasan>), mematikannya (.afk off),
+// atau menampilkan dan mengubah pengaturan AFK.
+func (h *Handler) AFKCommand(c Command) (whatsmeow.SendResponse, error) {
+	action := strings.ToLower(c.parsed.String("aksi"))
+	value := restAfterFirst(c.rawArgs)
+
+	switch action {
+	case "", "status":
+		return h.sendReply(c, h.renderAFKSettings())
+
+	case "off", "back":
+		ended, err := h.endManualAFK(c.ctx)
+		if err != nil {
+			return whatsmeow.SendResponse{}, fmt.Errorf("gagal mematikan AFK manual: %w", err)
+		}
+		if !ended {
+			return whatsmeow.SendResponse{}, userErrorf("AFK manual sedang tidak aktif")
+		}
+		return h.sendReply(c, "Selamat datang kembali! Rangkuman pesan sudah dikirim ke DM.")
+
+	case "schedule", "jadwal":
+		if value == "" {
+			return h.sendReply(c, h.renderAFKSettings())
+		}
+		schedule, err := afk.ParseSchedule(value)
+		if err != nil {
+			return whatsmeow.SendResponse{}, &UserError{Msg: "Jadwal tidak valid", Err: err}
+		}
+		if err := h.afk.SetSchedule(c.ctx, schedule); err != nil {
+			return whatsmeow.SendResponse{}, fmt.Errorf("gagal menyimpan jadwal AFK: %w", err)
+		}
+		return h.sendReply(c, "Jadwal AFK diperbarui.\n\n"+h.renderAFKSettings())
+
+	case "reload":
+		calendar := h.afk.Calendar()
+		if calendar == nil {
+			return whatsmeow.SendResponse{}, userErrorf("Tidak ada file kalender. Atur AFK_CALENDARS terlebih dahulu")
+		}
+		count, err := calendar.Reload()
+		if err != nil {
+			return whatsmeow.SendResponse{}, &UserError{Msg: fmt.Sprintf("%d event dimuat, tapi sebagian kalender atau event gagal dibaca", count), Err: err}
+		}
+		return h.sendReply(c, fmt.Sprintf("Kalender dimuat ulang, %d event.\n\n%s", count, h.renderAFKSettings()))
+
+	case "timezone", "tz":
+		if value == "" {
+			return whatsmeow.SendResponse{}, userErrorf("Sebutkan zona waktu, misal: %safk timezone Asia/Jakarta", h.prefix)
+		}
+		if err := h.afk.SetTimezone(c.ctx, value); err != nil {
+			return whatsmeow.SendResponse{}, &UserError{Msg: "Zona waktu tidak valid", Err: err}
+		}
+		return h.sendReply(c, "Zona waktu AFK diperbarui.\n\n"+h.renderAFKSettings())
+
+	case "renotify", "interval":
+		if value == "" {
+			return whatsmeow.SendResponse{}, userErrorf("Sebutkan interval, misal: %safk renotify 2h (0 = sekali per periode AFK)", h.prefix)
+		}
+		d, err := durations.Parse(value)
+		if err != nil {
+			return whatsmeow.SendResponse{}, &UserError{Msg: "Interval tidak valid", Err: err}
+		}
+		if err := h.afk.SetRenotifyInterval(c.ctx, d); err != nil {
+			return whatsmeow.SendResponse{}, fmt.Errorf("gagal menyimpan interval AFK: %w", err)
+		}
+		return h.sendReply(c, "Interval balasan ulang AFK diperbarui.\n\n"+h.renderAFKSettings())
+
+	case "message", "pesan":
+		if value == "" {
+			return whatsmeow.SendResponse{}, userErrorf("Tulis template pesannya. Placeholder: {name}, {sender}, {until}")
+		}
+		if err := h.afk.SetMessage(c.ctx, value); err != nil {
+			return whatsmeow.SendResponse{}, fmt.Errorf("gagal menyimpan pesan AFK: %w", err)
+		}
+		return h.sendReply(c, "Pesan AFK diperbarui.\n\n"+h.renderAFKSettings())
+
+	default:
+		// Selain subperintah di atas, seluruh teks dianggap alasan AFK manual.
+		reason := strings.TrimSpace(c.rawArgs)
+		if err := h.afk.StartManual(c.ctx, reason, time.Now()); err != nil {
+			return whatsmeow.SendResponse{}, fmt.Errorf("gagal menyalakan AFK: %w", err)
+		}
+		return h.sendReply(c, fmt.Sprintf("💤 AFK aktif: _%s_\nKirim pesan apa pun untuk kembali.", reason))
+	}
+}
+
+// renderAFKSettings merakit ringkasan pengaturan AFK beserta status saat ini.
+func (h *Handler) renderAFKSettings() string {
+	settings := h.afk.Settings()
+	now := time.Now().In(h.afk.Location())
+
+	var sb strings.Builder
+	sb.WriteString("🌙 *PENGATURAN AFK*\n")
+	sb.WriteString("━━━━━━━━━━━━━━━━━━━━\n")
+	sb.WriteString(fmt.Sprintf("*Zona waktu:* %s (sekarang %s)\n", settings.Timezone, now.Format("15:04")))
+
+	sb.WriteString("*Jadwal:*\n")
+	if len(settings.Schedule) == 0 {
+		sb.WriteString("_tidak ada_\n")
+	}
+	for _, w := range settings.Schedule {
+		sb.WriteString(fmt.Sprintf("• `%s`\n", w))
+	}
+
+	if settings.RenotifyInterval == "0s" || settings.RenotifyInterval == "0" {
+		sb.WriteString("*Balas ulang:* sekali per periode AFK\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("*Balas ulang:* setiap %s per chat\n", settings.RenotifyInterval))
+	}
+
+	switch status := h.afk.Status(now); {
+	case status.Manual != nil:
+		sb.WriteString(fmt.Sprintf("*Status:* AFK manual sejak %s (%s)\n", status.Manual.Since.In(now.Location()).Format("15:04"), status.Manual.Reason))
+	case status.Event != nil:
+		sb.WriteString(fmt.Sprintf("*Status:* AFK karena event _%s_ (%s) sampai %s\n", status.Event.Summary, status.Event.Source, formatUntil(status.Until, now)))
+	case status.Active:
+		sb.WriteString(fmt.Sprintf("*Status:* AFK sampai %s\n", formatUntil(status.Until, now)))
+	default:
+		sb.WriteString("*Status:* tidak AFK\n")
+	}
+
+	if calendar := h.afk.Calendar(); calendar != nil {
+		sb.WriteString(fmt.Sprintf("*Kalender:* %s\n", strings.Join(calendar.Paths(), ", ")))
+		if err := calendar.LastError(); err != nil {
+			sb.WriteString(fmt.Sprintf("⚠️ _%v_\n", err))
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("\n*Pesan:*\n_%s_\n", settings.Message))
+	sb.WriteString(fmt.Sprintf("\n*Pesan AFK manual:*\n_%s_", settings.ManualMessage))
+	return sb.String()
+}
+
+// restAfterFirst mengembalikan teks mentah setelah token pertama, tanpa memecah spasi
+// dan baris baru di dalamnya. Dipakai untuk argumen bebas seperti template pesan.
+func restAfterFirst(raw string) string {
+	raw = strings.TrimLeftFunc(raw, unicode.IsSpace)
+	end := strings.IndexFunc(raw, unicode.IsSpace)
+	if end < 0 {
+		return ""
+	}
+	return strings.TrimSpace(raw[end:])
+}
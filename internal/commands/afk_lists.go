@@ -0,0 +1,226 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Satr10/wa-userbot/internal/afk"
+	"github.com/Satr10/wa-userbot/internal/permissions"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// webhookTimeout membatasi lama satu pengiriman webhook VIP.
+const webhookTimeout = 10 * time.Second
+
+// vipWebhookQueueSize adalah jumlah webhook VIP yang boleh mengantre sebelum yang baru ditolak.
+const vipWebhookQueueSize = 16
+
+// AFKListCommand mengelola daftar ignore dan VIP untuk AFK.
+func (h *Handler) AFKListCommand(c Command) (whatsmeow.SendResponse, error) {
+	action := strings.ToLower(c.parsed.String("aksi"))
+	if action == "list" {
+		return h.sendReply(c, h.renderAFKLists(c.ctx))
+	}
+
+	targets := h.commandTargets(c, "target")
+	here := c.parsed.Bool("here")
+	if len(targets) == 0 && !here {
+		return whatsmeow.SendResponse{}, userErrorf("Sebutkan target dengan @mention, nomor, reply pesan, atau --here. Penggunaan: %s", h.usageLine(&c))
+	}
+
+	switch action {
+	case permissions.AFKIgnore, permissions.AFKVIP:
+		var added []string
+		for _, target := range targets {
+			id := h.identity.Canonical(c.ctx, target).String()
+			if err := h.perm.SetAFKList(id, action); err != nil {
+				return whatsmeow.SendResponse{}, fmt.Errorf("gagal menambahkan %s ke daftar AFK %s: %w", id, action, err)
+			}
+			added = append(added, target.User)
+		}
+		if here {
+			chat := c.evt.Info.Chat
+			if !c.evt.Info.IsGroup {
+				chat = h.identity.Canonical(c.ctx, chat)
+			}
+			if err := h.perm.SetAFKList(chat.String(), action); err != nil {
+				return whatsmeow.SendResponse{}, fmt.Errorf("gagal menambahkan %s ke daftar AFK %s: %w", chat, action, err)
+			}
+			added = append(added, "chat ini")
+		}
+		return h.sendReply(c, fmt.Sprintf("Ditambahkan ke daftar AFK *%s*: %s", action, strings.Join(added, ", ")))
+
+	case "del":
+		var removed []string
+		for _, target := range targets {
+			for _, id := range h.identity.Aliases(c.ctx, target) {
+				if err := h.perm.RemoveAFKList(id.String()); err != nil {
+					return whatsmeow.SendResponse{}, fmt.Errorf("gagal menghapus %s dari daftar AFK: %w", id, err)
+				}
+			}
+			removed = append(removed, target.User)
+		}
+		if here {
+			for _, id := range h.identity.Aliases(c.ctx, c.evt.Info.Chat) {
+				if err := h.perm.RemoveAFKList(id.String()); err != nil {
+					return whatsmeow.SendResponse{}, fmt.Errorf("gagal menghapus %s dari daftar AFK: %w", id, err)
+				}
+			}
+			removed = append(removed, "chat ini")
+		}
+		return h.sendReply(c, fmt.Sprintf("Dihapus dari daftar AFK: %s", strings.Join(removed, ", ")))
+
+	default:
+		return whatsmeow.SendResponse{}, userErrorf("Aksi %q tidak dikenal, gunakan ignore, vip, del atau list", action)
+	}
+}
+
+// renderAFKLists merakit isi daftar ignore dan VIP beserta status jalur darurat.
+func (h *Handler) renderAFKLists(ctx context.Context) string {
+	lists := h.perm.Snapshot().AFKLists
+
+	var sb strings.Builder
+	sb.WriteString("🌙 *DAFTAR AFK*\n")
+	sb.WriteString("━━━━━━━━━━━━━━━━━━━━\n")
+
+	for _, list := range []string{permissions.AFKVIP, permissions.AFKIgnore} {
+		sb.WriteString(fmt.Sprintf("\n*%s*\n", strings.ToUpper(list)))
+		count := 0
+		for _, id := range slices.Sorted(maps.Keys(lists)) {
+			if lists[id] != list {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("• %s\n", h.displayNameOf(ctx, id)))
+			count++
+		}
+		if count == 0 {
+			sb.WriteString("_kosong_\n")
+		}
+	}
+
+	sb.WriteString("\n*Jalur darurat VIP:*\n")
+	if h.vipForward.IsEmpty() && h.cfg.AFKVIPWebhook == "" {
+		sb.WriteString("_tidak diatur, VIP dibalas seperti biasa_")
+		return sb.String()
+	}
+	if !h.vipForward.IsEmpty() {
+		sb.WriteString(fmt.Sprintf("• Teruskan ke %s\n", h.vipForward.User))
+	}
+	if h.cfg.AFKVIPWebhook != "" {
+		sb.WriteString("• Webhook aktif\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// vipWebhookPayload adalah isi POST JSON yang dikirim ke AFK_VIP_WEBHOOK.
+type vipWebhookPayload struct {
+	Event      string    `json:"event"`
+	ChatID     string    `json:"chatId"`
+	SenderID   string    `json:"senderId"`
+	SenderName string    `json:"senderName"`
+	MessageID  string    `json:"messageId"`
+	Text       string    `json:"text"`
+	Time       time.Time `json:"time"`
+	IsGroup    bool      `json:"isGroup"`
+	AFKReason  string    `json:"afkReason,omitempty"`
+}
+
+// notifyVIP meneruskan pesan VIP lewat jalur darurat yang dikonfigurasi (nomor kedua dan/atau webhook).
+// Webhook dikirim di latar belakang oleh runVIPWebhooks; masuk antrean sudah dihitung terkirim.
+// Mengembalikan false jika tidak ada jalur yang berhasil, sehingga pemanggil bisa membalas seperti biasa.
+func (h *Handler) notifyVIP(ctx context.Context, evt *events.Message, msgText string, status afk.Status) bool {
+	delivered := false
+
+	if !h.vipForward.IsEmpty() {
+		var sb strings.Builder
+		sb.WriteString("🚨 *PESAN VIP SAAT AFK*\n")
+		sb.WriteString("━━━━━━━━━━━━━━━━━━━━\n")
+		sb.WriteString(fmt.Sprintf("*Dari:* %s\n", h.displayName(ctx, evt.Info.Sender.ToNonAD())))
+		if evt.Info.IsGroup {
+			sb.WriteString(fmt.Sprintf("*Grup:* %s\n", h.displayName(ctx, evt.Info.Chat)))
+		}
+		sb.WriteString(fmt.Sprintf("*Waktu:* %s\n\n", evt.Info.Timestamp.In(h.afk.Location()).Format("02/01 15:04")))
+		sb.WriteString(msgText)
+
+		if _, err := SendTextToJID(ctx, h.client, h.vipForward, sb.String()); err != nil {
+			h.logger.Errorf("error forwarding VIP message to %s, err: %v", h.vipForward, err)
+		} else {
+			delivered = true
+		}
+	}
+
+	if h.cfg.AFKVIPWebhook != "" {
+		payload := vipWebhookPayload{
+			Event:      "afk_vip_message",
+			ChatID:     evt.Info.Chat.String(),
+			SenderID:   evt.Info.Sender.ToNonAD().String(),
+			SenderName: evt.Info.PushName,
+			MessageID:  evt.Info.ID,
+			Text:       msgText,
+			Time:       evt.Info.Timestamp,
+			IsGroup:    evt.Info.IsGroup,
+		}
+		if status.Manual != nil {
+			payload.AFKReason = status.Manual.Reason
+		}
+		select {
+		case h.vipWebhooks <- payload:
+			delivered = true
+		default:
+			h.logger.Errorf("VIP webhook queue is full, dropping message %s", evt.Info.ID)
+		}
+	}
+
+	if delivered {
+		h.logger.Infof("VIP message from %s delivered through urgent path", evt.Info.Sender)
+	}
+	return delivered
+}
+
+// runVIPWebhooks mengirim webhook VIP dari antrean satu per satu, terpisah dari event handler
+// sehingga webhook yang lambat tidak menahan pemrosesan pesan.
+func (h *Handler) runVIPWebhooks() {
+	for payload := range h.vipWebhooks {
+		if err := h.postWebhook(context.Background(), h.cfg.AFKVIPWebhook, payload); err != nil {
+			h.logger.Errorf("error sending VIP webhook for message %s, err: %v", payload.MessageID, err)
+		}
+	}
+}
+
+// postWebhook mengirim body sebagai JSON ke url dan menganggap status selain 2xx sebagai kegagalan.
+func (h *Handler) postWebhook(ctx context.Context, url string, body any) error {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook body: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.webhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,447 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Satr10/wa-userbot/internal/durations"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// ArgType menentukan bagaimana sebuah token argumen dikonversi.
+type ArgType int
+
+const (
+	ArgString ArgType = iota
+	ArgInt
+	ArgDuration
+	// ArgJID menerima @mention, nomor telepon, atau JID lengkap.
+	ArgJID
+	// ArgBool hanya berlaku untuk flag; kehadiran flag berarti true.
+	ArgBool
+)
+
+func (t ArgType) String() string {
+	switch t {
+	case ArgInt:
+		return "angka"
+	case ArgDuration:
+		return "durasi"
+	case ArgJID:
+		return "@user"
+	case ArgBool:
+		return "bool"
+	default:
+		return "teks"
+	}
+}
+
+// ArgSpec mendefinisikan satu argumen posisional.
+type ArgSpec struct {
+	Name     string
+	Type     ArgType
+	Optional bool
+	// Variadic menampung semua token sisa; hanya boleh dipakai pada argumen terakhir.
+	Variadic bool
+}
+
+// FlagSpec mendefinisikan satu opsi berbentuk --nama=nilai atau --nama nilai.
+type FlagSpec struct {
+	Name    string
+	Type    ArgType
+	Default string
+}
+
+// localCountryCode dipakai untuk mengubah nomor lokal berawalan 0 ke format internasional.
+const localCountryCode = "62"
+
+// minPhoneDigits adalah jumlah digit minimal nomor telepon; token angka yang lebih pendek
+// kemungkinan salah ketik dan tidak diubah menjadi JID.
+const minPhoneDigits = 8
+
+// completePhoneDigits adalah panjang (termasuk kode negara) yang dianggap sudah nomor lengkap
+// ketika menyatukan nomor yang ditulis dengan spasi.
+const completePhoneDigits = 10
+
+// ErrUsage menandai kesalahan input dari pengguna sehingga balasan berisi format penggunaan.
+var ErrUsage = errors.New("penggunaan tidak valid")
+
+// usageError membungkus ErrUsage dengan pesan yang bisa langsung ditampilkan ke pengguna.
+func usageError(format string, a ...any) error {
+	return fmt.Errorf("%w: %s", ErrUsage, fmt.Sprintf(format, a...))
+}
+
+// ParsedArgs menyimpan hasil parsing argumen yang sudah dikonversi sesuai tipenya.
+type ParsedArgs struct {
+	values map[string][]any
+}
+
+// Has melaporkan apakah argumen atau flag diberikan (atau punya nilai default).
+func (p ParsedArgs) Has(name string) bool {
+	return len(p.values[name]) > 0
+}
+
+func (p ParsedArgs) first(name string) any {
+	if v := p.values[name]; len(v) > 0 {
+		return v[0]
+	}
+	return nil
+}
+
+// String mengembalikan argumen bertipe teks, atau "" jika tidak ada.
+func (p ParsedArgs) String(name string) string {
+	s, _ := p.first(name).(string)
+	return s
+}
+
+// Strings mengembalikan semua nilai argumen variadic bertipe teks.
+func (p ParsedArgs) Strings(name string) []string {
+	var out []string
+	for _, v := range p.values[name] {
+		if s, ok := v.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Int mengembalikan argumen bertipe angka, atau 0 jika tidak ada.
+func (p ParsedArgs) Int(name string) int {
+	i, _ := p.first(name).(int)
+	return i
+}
+
+// Duration mengembalikan argumen bertipe durasi, atau 0 jika tidak ada.
+func (p ParsedArgs) Duration(name string) time.Duration {
+	d, _ := p.first(name).(time.Duration)
+	return d
+}
+
+// Bool mengembalikan nilai flag boolean.
+func (p ParsedArgs) Bool(name string) bool {
+	b, _ := p.first(name).(bool)
+	return b
+}
+
+// JID mengembalikan argumen bertipe JID, atau JID kosong jika tidak ada.
+func (p ParsedArgs) JID(name string) types.JID {
+	jid, _ := p.first(name).(types.JID)
+	return jid
+}
+
+// JIDs mengembalikan semua nilai argumen variadic bertipe JID.
+func (p ParsedArgs) JIDs(name string) []types.JID {
+	var out []types.JID
+	for _, v := range p.values[name] {
+		if jid, ok := v.(types.JID); ok {
+			out = append(out, jid)
+		}
+	}
+	return out
+}
+
+// splitArgs memecah teks menjadi token seperti shell sederhana:
+// spasi memisahkan token, "kutip ganda" dan 'kutip tunggal' menyatukan frasa,
+// dan backslash meloloskan karakter berikutnya di luar kutip tunggal.
+func splitArgs(text string) ([]string, error) {
+	var tokens []string
+	var current strings.Builder
+	inToken := false
+	var quote rune
+	escaped := false
+
+	for _, r := range text {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inToken = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inToken = true
+		case unicode.IsSpace(r):
+			if inToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, usageError("tanda kutip %c tidak ditutup", quote)
+	}
+	if escaped {
+		current.WriteRune('\\')
+	}
+	if inToken {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+// parseArgs mencocokkan token dengan skema argumen dan flag milik perintah.
+func (cmd *Command) parseArgs(tokens []string, evt *events.Message) (ParsedArgs, error) {
+	parsed := ParsedArgs{values: make(map[string][]any)}
+	var positional []string
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if token == "--" {
+			positional = append(positional, tokens[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(token, "--") || len(token) == 2 {
+			positional = append(positional, token)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(token, "--"), "=")
+		flag, ok := cmd.flag(name)
+		if !ok {
+			return parsed, usageError("opsi --%s tidak dikenal", name)
+		}
+
+		if flag.Type == ArgBool {
+			if !hasValue {
+				parsed.values[flag.Name] = []any{true}
+				continue
+			}
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return parsed, usageError("opsi --%s harus true/false", name)
+			}
+			parsed.values[flag.Name] = []any{b}
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(tokens) {
+				return parsed, usageError("opsi --%s membutuhkan nilai", name)
+			}
+			i++
+			value = tokens[i]
+		}
+		v, err := convertArg(value, flag.Type, evt)
+		if err != nil {
+			return parsed, usageError("opsi --%s: %v", name, err)
+		}
+		parsed.values[flag.Name] = []any{v}
+	}
+
+	// Isi default untuk flag yang tidak diberikan.
+	for _, flag := range cmd.Flags {
+		if parsed.Has(flag.Name) || flag.Default == "" {
+			continue
+		}
+		v, err := convertArg(flag.Default, flag.Type, evt)
+		if err != nil {
+			return parsed, fmt.Errorf("default opsi --%s tidak valid: %w", flag.Name, err)
+		}
+		parsed.values[flag.Name] = []any{v}
+	}
+
+	pos := 0
+	for _, spec := range cmd.Args {
+		if pos >= len(positional) {
+			if !spec.Optional {
+				return parsed, usageError("argumen <%s> wajib diisi", spec.Name)
+			}
+			break
+		}
+
+		for pos < len(positional) {
+			token, used := positional[pos], 1
+			if spec.Type == ArgJID {
+				token, used = joinPhoneTokens(positional[pos:])
+			}
+			v, err := convertArg(token, spec.Type, evt)
+			if err != nil {
+				return parsed, usageError("argumen <%s>: %v", spec.Name, err)
+			}
+			parsed.values[spec.Name] = append(parsed.values[spec.Name], v)
+			pos += used
+			if !spec.Variadic {
+				break
+			}
+		}
+	}
+
+	if pos < len(positional) {
+		return parsed, usageError("terlalu banyak argumen")
+	}
+
+	return parsed, nil
+}
+
+// flag mencari definisi flag berdasarkan nama.
+func (cmd *Command) flag(name string) (FlagSpec, bool) {
+	for _, f := range cmd.Flags {
+		if strings.EqualFold(f.Name, name) {
+			return f, true
+		}
+	}
+	return FlagSpec{}, false
+}
+
+// convertArg mengubah token mentah menjadi nilai sesuai ArgType.
+func convertArg(token string, argType ArgType, evt *events.Message) (any, error) {
+	switch argType {
+	case ArgInt:
+		i, err := strconv.Atoi(token)
+		if err != nil {
+			return nil, fmt.Errorf("%q bukan angka", token)
+		}
+		return i, nil
+	case ArgDuration:
+		d, err := durations.Parse(token)
+		if err != nil {
+			return nil, fmt.Errorf("%q bukan durasi (contoh: 30s, 5m, 2h, 1d)", token)
+		}
+		return d, nil
+	case ArgJID:
+		jid, err := parseJIDArg(token, evt)
+		if err != nil {
+			return nil, err
+		}
+		return jid, nil
+	case ArgBool:
+		b, err := strconv.ParseBool(token)
+		if err != nil {
+			return nil, fmt.Errorf("%q harus true/false", token)
+		}
+		return b, nil
+	default:
+		return token, nil
+	}
+}
+
+// joinPhoneTokens menyatukan kembali nomor telepon yang ditulis dengan spasi, misal
+// "+62 812-3456-7890", yang oleh splitArgs terpecah menjadi beberapa token. Potongan berikutnya
+// hanya digabung selama nomor belum mencapai panjang minimal nomor lengkap, sehingga beberapa
+// nomor utuh yang dipisah spasi tetap dibaca sebagai nomor terpisah. Mengembalikan token hasil
+// gabungan dan jumlah token yang dipakai.
+func joinPhoneTokens(tokens []string) (string, int) {
+	joined := tokens[0]
+	if !isPhoneFragment(strings.TrimPrefix(joined, "+")) {
+		return joined, 1
+	}
+	used := 1
+	for used < len(tokens) && countDigits(joined) < completePhoneDigits {
+		next := tokens[used]
+		if !isPhoneFragment(next) || strings.HasPrefix(next, "0") {
+			break
+		}
+		joined += next
+		used++
+	}
+	return joined, used
+}
+
+// isPhoneFragment melaporkan apakah token hanya berisi angka dan tanda hubung.
+func isPhoneFragment(token string) bool {
+	return token != "" && strings.Trim(token, "0123456789-") == ""
+}
+
+func countDigits(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			n++
+		}
+	}
+	return n
+}
+
+// parseJIDArg mengubah @mention, nomor telepon atau JID lengkap menjadi types.JID.
+// Untuk @mention, JID diambil dari daftar mention pesan agar LID di grup tetap cocok.
+func parseJIDArg(token string, evt *events.Message) (types.JID, error) {
+	if strings.HasPrefix(token, "@") {
+		user := strings.TrimPrefix(token, "@")
+		for _, mentioned := range mentionedJIDs(evt) {
+			jid, err := types.ParseJID(mentioned)
+			if err == nil && jid.User == user {
+				return jid, nil
+			}
+		}
+		token = user
+	}
+
+	if strings.Contains(token, "@") {
+		jid, err := types.ParseJID(token)
+		if err != nil {
+			return types.JID{}, fmt.Errorf("%q bukan JID yang valid", token)
+		}
+		return jid.ToNonAD(), nil
+	}
+
+	phone := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		if r == '+' || r == '-' || r == ' ' {
+			return -1
+		}
+		return 'x'
+	}, token)
+	if phone == "" || strings.ContainsRune(phone, 'x') {
+		return types.JID{}, fmt.Errorf("%q bukan mention atau nomor telepon", token)
+	}
+	if len(phone) < minPhoneDigits {
+		return types.JID{}, fmt.Errorf("%q terlalu pendek untuk nomor telepon", token)
+	}
+	// Nomor lokal (08xx) diubah ke format internasional.
+	if local, ok := strings.CutPrefix(phone, "0"); ok {
+		phone = localCountryCode + local
+	}
+	return types.NewJID(phone, types.DefaultUserServer), nil
+}
+
+// mentionedJIDs mengembalikan daftar JID yang di-mention dalam pesan, jika ada.
+func mentionedJIDs(evt *events.Message) []string {
+	if evt == nil || evt.Message == nil {
+		return nil
+	}
+	return evt.Message.GetExtendedTextMessage().GetContextInfo().GetMentionedJID()
+}
+
+// generatedUsage membuat string penggunaan dari skema argumen dan flag,
+// dipakai ketika Command.Usage tidak diisi manual.
+func (cmd *Command) generatedUsage() string {
+	var parts []string
+	for _, spec := range cmd.Args {
+		name := spec.Name
+		if spec.Variadic {
+			name += "..."
+		}
+		if spec.Optional {
+			parts = append(parts, "["+name+"]")
+		} else {
+			parts = append(parts, "<"+name+">")
+		}
+	}
+	for _, flag := range cmd.Flags {
+		if flag.Type == ArgBool {
+			parts = append(parts, fmt.Sprintf("[--%s]", flag.Name))
+		} else {
+			parts = append(parts, fmt.Sprintf("[--%s=<%s>]", flag.Name, flag.Type))
+		}
+	}
+	return strings.Join(parts, " ")
+}
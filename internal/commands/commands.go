@@ -1,8 +1,15 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/Satr10/wa-userbot/internal/permissions"
 	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
 )
 
 const Footer = "\n\n_pesan otomatis oleh bot_"
@@ -29,6 +36,24 @@ func (h *Handler) editMessage(c Command, message string, messageID string) (what
 	return EditMessage(textMsg, messageID)
 }
 
+// HelpCommand menampilkan menu perintah yang boleh dipakai pengirim, atau detail satu perintah.
+func (h *Handler) HelpCommand(c Command) (whatsmeow.SendResponse, error) {
+	sender := c.evt.Info.Sender.ToNonAD()
+	chat := c.evt.Info.Chat
+
+	if !c.parsed.Has("perintah") {
+		return h.sendReply(c, h.renderHelpMenu(h.visibleCommands(sender, chat)))
+	}
+
+	name := strings.TrimPrefix(c.parsed.String("perintah"), h.prefix)
+	cmd, exists := h.lookup(name)
+	// Perintah yang tidak boleh dijalankan pengirim diperlakukan seolah tidak ada.
+	if !exists || !h.checkPermission(sender, chat, cmd) {
+		return h.sendReply(c, fmt.Sprintf("Perintah `%s%s` tidak ditemukan. Ketik `%shelp` untuk melihat daftar perintah.", h.prefix, name, h.prefix))
+	}
+	return h.sendReply(c, h.renderCommandHelp(cmd))
+}
+
 // PingCommand handles the ping command
 func (h *Handler) PingCommand(c Command) (whatsmeow.SendResponse, error) {
 	// Log aktivitas jika diperlukan
@@ -49,61 +74,102 @@ func (h *Handler) EditMsgTest(c Command) (whatsmeow.SendResponse, error) {
 	return h.editMessage(c, "Pong Edit", firstMsg.ID)
 }
 
-// AddUserCommand menambahkan user ke daftar yang diizinkan
-// func (h *Handler) AddUserCommand(c Command) (whatsmeow.SendResponse, error) {
-// 	userIDs := c.evt.Message.ExtendedTextMessage.GetContextInfo().GetMentionedJID()
-// 	// Validasi input
-// 	if len(userIDs) == 0 {
-// 		return h.sendReply(c, "Penggunaan: .adduser <user_id>")
-// 	}
-//
-// 	// Eksekusi penambahan user
-// 	for _, userID := range userIDs {
-// 		if err := h.perm.AddAllowedUser(userID); err != nil {
-// 			errorMsg := fmt.Sprintf("Gagal menambahkan pengguna: %v", err)
-// 			return h.sendReply(c, errorMsg)
-// 		}
-// 	}
-//
-// 	// Kirim pesan sukses
-// 	successMsg := fmt.Sprintf("Pengguna %s berhasil ditambahkan.", userIDs)
-// 	return h.sendReply(c, successMsg)
-// }
-//
-// // DelUserCommand menghapus user dari daftar yang diizinkan
-// func (h *Handler) DelUserCommand(c Command) (whatsmeow.SendResponse, error) {
-// 	userIDs := c.evt.Message.ExtendedTextMessage.GetContextInfo().GetMentionedJID()
-// 	// Validasi input
-// 	if len(userIDs) == 0 {
-// 		return h.sendReply(c, "Penggunaan: .deluser <user_id>")
-// 	}
-//
-// 	// Eksekusi penghapusan user
-// 	for _, userID := range userIDs {
-// 		if err := h.perm.RemoveAllowedUser(userID); err != nil {
-// 			errorMsg := fmt.Sprintf("Gagal menghapus pengguna: %v", err)
-// 			return h.sendReply(c, errorMsg)
-// 		}
-//
-// 	}
-// 	// Kirim pesan sukses
-// 	successMsg := fmt.Sprintf("Pengguna %s berhasil dihapus.", userIDs)
-// 	return h.sendReply(c, successMsg)
-// }
+// AddUserCommand menambahkan user ke daftar yang diizinkan.
+// Target bisa berupa @mention, nomor telepon, atau pengirim pesan yang di-reply.
+func (h *Handler) AddUserCommand(c Command) (whatsmeow.SendResponse, error) {
+	targets := h.commandTargets(c, "user")
+	// Validasi input
+	if len(targets) == 0 {
+		return whatsmeow.SendResponse{}, userErrorf("Sebutkan pengguna dengan @mention, nomor telepon, atau reply pesannya")
+	}
+
+	// Eksekusi penambahan user. Disimpan dalam bentuk kanonik (nomor telepon jika diketahui);
+	// LID-nya tetap dikenali saat pengecekan izin.
+	var names []string
+	for _, target := range targets {
+		id := h.identity.Canonical(c.ctx, target)
+		if err := h.perm.AddAllowedUser(id.String()); err != nil {
+			return whatsmeow.SendResponse{}, fmt.Errorf("gagal menambahkan pengguna %s: %w", id, err)
+		}
+		names = append(names, h.displayName(c.ctx, id))
+	}
+
+	// Kirim pesan sukses
+	successMsg := fmt.Sprintf("Pengguna berhasil ditambahkan:\n• %s", strings.Join(names, "\n• "))
+	return h.sendReply(c, successMsg)
+}
+
+// DelUserCommand menghapus user dari daftar yang diizinkan.
+func (h *Handler) DelUserCommand(c Command) (whatsmeow.SendResponse, error) {
+	targets := h.commandTargets(c, "user")
+	// Validasi input
+	if len(targets) == 0 {
+		return whatsmeow.SendResponse{}, userErrorf("Sebutkan pengguna dengan @mention, nomor telepon, atau reply pesannya")
+	}
+
+	// Eksekusi penghapusan user, termasuk pasangan nomor telepon/LID-nya
+	var names []string
+	for _, target := range targets {
+		for _, id := range h.identity.Aliases(c.ctx, target) {
+			if err := h.perm.RemoveAllowedUser(id.String()); err != nil {
+				return whatsmeow.SendResponse{}, fmt.Errorf("gagal menghapus pengguna %s: %w", id, err)
+			}
+		}
+		names = append(names, h.displayName(c.ctx, target))
+	}
+
+	// Kirim pesan sukses
+	successMsg := fmt.Sprintf("Pengguna berhasil dihapus:\n• %s", strings.Join(names, "\n• "))
+	return h.sendReply(c, successMsg)
+}
+
+// ListAllowedCommand menampilkan semua pengguna dan grup yang diizinkan beserta namanya.
+func (h *Handler) ListAllowedCommand(c Command) (whatsmeow.SendResponse, error) {
+	perm := h.perm.Snapshot()
+
+	var sb strings.Builder
+	sb.WriteString("🔐 *DAFTAR IZIN*\n")
+	sb.WriteString("━━━━━━━━━━━━━━━━━━━━\n")
+
+	sb.WriteString(fmt.Sprintf("\n*Grup (%d)*\n", len(perm.AllowedGroups)))
+	if len(perm.AllowedGroups) == 0 {
+		sb.WriteString("_kosong_\n")
+	}
+	for _, id := range slices.Sorted(maps.Keys(perm.AllowedGroups)) {
+		sb.WriteString("• " + h.displayNameOf(c.ctx, id) + "\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("\n*Pengguna (%d)*\n", len(perm.AllowedUsers)))
+	if len(perm.AllowedUsers) == 0 {
+		sb.WriteString("_kosong_\n")
+	}
+	for _, id := range slices.Sorted(maps.Keys(perm.AllowedUsers)) {
+		sb.WriteString("• " + h.displayNameOf(c.ctx, id) + "\n")
+	}
+
+	return h.sendReply(c, strings.TrimRight(sb.String(), "\n"))
+}
+
+// displayNameOf seperti displayName, tetapi menerima JID dalam bentuk string dari data izin.
+func (h *Handler) displayNameOf(ctx context.Context, id string) string {
+	jid, err := types.ParseJID(id)
+	if err != nil {
+		return id
+	}
+	return h.displayName(ctx, jid)
+}
 
 // AddGroupCommand menambahkan grup ke daftar yang diizinkan
 func (h *Handler) AddGroupCommand(c Command) (whatsmeow.SendResponse, error) {
 	if !c.evt.Info.IsGroup {
-		errorMsg := ("Gagal menambahkan grup: chat ini bukan grup")
-		return h.sendReply(c, errorMsg)
+		return whatsmeow.SendResponse{}, userErrorf("Gagal menambahkan grup: chat ini bukan grup")
 	}
 	// Dapatkan group ID dari context
 	groupID := c.evt.Info.Chat.String()
 
 	// Eksekusi penambahan grup
 	if err := h.perm.AddAllowedGroup(groupID); err != nil {
-		errorMsg := fmt.Sprintf("Gagal menambahkan grup: %v", err)
-		return h.sendReply(c, errorMsg)
+		return whatsmeow.SendResponse{}, fmt.Errorf("gagal menambahkan grup %s: %w", groupID, err)
 	}
 
 	// Kirim pesan sukses
@@ -114,19 +180,164 @@ func (h *Handler) AddGroupCommand(c Command) (whatsmeow.SendResponse, error) {
 // DelGroupCommand menghapus grup dari daftar yang diizinkan
 func (h *Handler) DelGroupCommand(c Command) (whatsmeow.SendResponse, error) {
 	if !c.evt.Info.IsGroup {
-		errorMsg := ("Gagal menghapus grup: chat ini bukan grup")
-		return h.sendReply(c, errorMsg)
+		return whatsmeow.SendResponse{}, userErrorf("Gagal menghapus grup: chat ini bukan grup")
 	}
 	// Dapatkan group ID dari context
 	groupID := c.evt.Info.Chat.String()
 
 	// Eksekusi penghapusan grup
 	if err := h.perm.RemoveAllowedGroup(groupID); err != nil {
-		errorMsg := fmt.Sprintf("Gagal menghapus grup: %v", err)
-		return h.sendReply(c, errorMsg)
+		return whatsmeow.SendResponse{}, fmt.Errorf("gagal menghapus grup %s: %w", groupID, err)
 	}
 
 	// Kirim pesan sukses
 	successMsg := fmt.Sprintf("Grup ini (%s) berhasil dihapus.", groupID)
 	return h.sendReply(c, successMsg)
 }
+
+// RoleCommand mengelola role pengguna: .role add|del @user <role> [--group], .role list [@user]
+func (h *Handler) RoleCommand(c Command) (whatsmeow.SendResponse, error) {
+	action := strings.ToLower(c.parsed.String("aksi"))
+	target := c.parsed.JID("user")
+
+	// Dengan --group, role hanya berlaku di grup tempat perintah dijalankan.
+	groupID := ""
+	if c.parsed.Bool("group") {
+		if !c.evt.Info.IsGroup {
+			return whatsmeow.SendResponse{}, userErrorf("Opsi --group hanya bisa dipakai di dalam grup")
+		}
+		groupID = c.evt.Info.Chat.String()
+	}
+
+	switch action {
+	case "list":
+		if target.IsEmpty() {
+			return h.sendReply(c, h.renderRoleList())
+		}
+		roles := h.perm.UserRoles(groupIDOf(c.evt.Info.Chat), h.identity.Keys(c.ctx, target)...)
+		if len(roles) == 0 {
+			return h.sendReply(c, fmt.Sprintf("Pengguna %s tidak memiliki role.", target.User))
+		}
+		return h.sendReply(c, fmt.Sprintf("Role %s: %s", target.User, strings.Join(roles, ", ")))
+
+	case "add", "del":
+		role := strings.ToLower(c.parsed.String("role"))
+		if target.IsEmpty() || role == "" {
+			return whatsmeow.SendResponse{}, userErrorf("Penggunaan: %s", h.usageLine(&c))
+		}
+
+		scope := "global"
+		if groupID != "" {
+			scope = "grup ini"
+		}
+
+		if action == "add" {
+			id := h.identity.Canonical(c.ctx, target)
+			if err := h.perm.AddRole(id.String(), groupID, role); err != nil {
+				return whatsmeow.SendResponse{}, fmt.Errorf("gagal menambahkan role %s ke %s: %w", role, id, err)
+			}
+			return h.sendReply(c, fmt.Sprintf("Role *%s* (%s) diberikan ke %s.", role, scope, target.User))
+		}
+
+		for _, id := range h.identity.Aliases(c.ctx, target) {
+			if err := h.perm.RemoveRole(id.String(), groupID, role); err != nil {
+				return whatsmeow.SendResponse{}, fmt.Errorf("gagal mencabut role %s dari %s: %w", role, id, err)
+			}
+		}
+		return h.sendReply(c, fmt.Sprintf("Role *%s* (%s) dicabut dari %s.", role, scope, target.User))
+
+	default:
+		return whatsmeow.SendResponse{}, userErrorf("Aksi %q tidak dikenal, gunakan add, del atau list", action)
+	}
+}
+
+// renderRoleList merakit daftar semua role global dan per grup.
+func (h *Handler) renderRoleList() string {
+	perm := h.perm.Snapshot()
+
+	var sb strings.Builder
+	sb.WriteString("👥 *DAFTAR ROLE*\n")
+	sb.WriteString("━━━━━━━━━━━━━━━━━━━━\n")
+	if len(perm.Roles) == 0 && len(perm.GroupRoles) == 0 {
+		sb.WriteString("\n_Belum ada role yang diberikan._")
+		return sb.String()
+	}
+
+	if len(perm.Roles) > 0 {
+		sb.WriteString("\n*Global*\n")
+		for _, user := range slices.Sorted(maps.Keys(perm.Roles)) {
+			sb.WriteString(fmt.Sprintf("• %s: %s\n", user, strings.Join(perm.Roles[user], ", ")))
+		}
+	}
+	for _, group := range slices.Sorted(maps.Keys(perm.GroupRoles)) {
+		sb.WriteString(fmt.Sprintf("\n*Grup %s*\n", group))
+		users := perm.GroupRoles[group]
+		for _, user := range slices.Sorted(maps.Keys(users)) {
+			sb.WriteString(fmt.Sprintf("• %s: %s\n", user, strings.Join(users[user], ", ")))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// GrantCommand memberi izin (atau larangan dengan --deny) menjalankan perintah tertentu
+// kepada pengguna atau role, menimpa PermissionLevel default perintah tersebut.
+func (h *Handler) GrantCommand(c Command) (whatsmeow.SendResponse, error) {
+	command, subjects, display, err := h.grantTarget(c)
+	if err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+
+	allow := !c.parsed.Bool("deny")
+	if err := h.perm.SetGrant(command.Name, subjects[0], allow); err != nil {
+		return whatsmeow.SendResponse{}, fmt.Errorf("gagal menyimpan grant %s untuk %s: %w", command.Name, subjects[0], err)
+	}
+
+	if allow {
+		return h.sendReply(c, fmt.Sprintf("%s sekarang boleh menjalankan `%s%s`.", display, h.prefix, command.Name))
+	}
+	return h.sendReply(c, fmt.Sprintf("%s sekarang dilarang menjalankan `%s%s`.", display, h.prefix, command.Name))
+}
+
+// RevokeCommand menghapus grant yang dibuat lewat .grant.
+func (h *Handler) RevokeCommand(c Command) (whatsmeow.SendResponse, error) {
+	command, subjects, display, err := h.grantTarget(c)
+	if err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+
+	for _, subject := range subjects {
+		if err := h.perm.RemoveGrant(command.Name, subject); err != nil {
+			return whatsmeow.SendResponse{}, fmt.Errorf("gagal menghapus grant %s untuk %s: %w", command.Name, subject, err)
+		}
+	}
+	return h.sendReply(c, fmt.Sprintf("Grant `%s%s` untuk %s dihapus.", h.prefix, command.Name, display))
+}
+
+// grantTarget membaca argumen <perintah> <target> milik .grant dan .revoke.
+// Target berupa @mention atau nomor untuk pengguna, selain itu dianggap nama role.
+// Subject pertama adalah bentuk kanonik untuk disimpan; sisanya identitas lain pengguna yang sama.
+func (h *Handler) grantTarget(c Command) (*Command, []string, string, error) {
+	name := strings.TrimPrefix(c.parsed.String("perintah"), h.prefix)
+	command, exists := h.lookup(name)
+	if !exists {
+		return nil, nil, "", userErrorf("Perintah `%s%s` tidak ditemukan", h.prefix, name)
+	}
+
+	target := c.parsed.String("target")
+	if role, ok := strings.CutPrefix(target, "role:"); ok {
+		role = strings.ToLower(role)
+		return command, []string{permissions.RoleSubject(role)}, "Role *" + role + "*", nil
+	}
+	if jid, err := parseJIDArg(target, c.evt); err == nil {
+		canonical := h.identity.Canonical(c.ctx, jid)
+		subjects := []string{permissions.UserSubject(canonical.String())}
+		for _, key := range h.identity.Keys(c.ctx, canonical) {
+			if key != canonical.String() {
+				subjects = append(subjects, permissions.UserSubject(key))
+			}
+		}
+		return command, subjects, jid.User, nil
+	}
+	role := strings.ToLower(target)
+	return command, []string{permissions.RoleSubject(role)}, "Role *" + role + "*", nil
+}
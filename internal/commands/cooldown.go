@@ -0,0 +1,268 @@
+package commands
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Satr10/wa-userbot/internal/durations"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// RateLimit adalah token bucket: maksimal Count pemakaian, terisi kembali Count token setiap Per.
+// Nilai nol berarti tidak dibatasi.
+type RateLimit struct {
+	Count int
+	Per   time.Duration
+}
+
+func (r RateLimit) unlimited() bool {
+	return r.Count <= 0 || r.Per <= 0
+}
+
+// parseRateLimit membaca format "<jumlah>/<durasi>", misal "3/10s". String kosong berarti tidak dibatasi.
+func parseRateLimit(s string) (RateLimit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return RateLimit{}, nil
+	}
+	countStr, perStr, ok := strings.Cut(s, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("format rate limit %q tidak valid, gunakan <jumlah>/<durasi>", s)
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil || count < 0 {
+		return RateLimit{}, fmt.Errorf("jumlah rate limit %q tidak valid", countStr)
+	}
+	per, err := durations.Parse(perStr)
+	if err != nil {
+		return RateLimit{}, fmt.Errorf("durasi rate limit %q tidak valid: %w", perStr, err)
+	}
+	return RateLimit{Count: count, Per: per}, nil
+}
+
+// Cooldown mengatur batas pemakaian satu perintah per pengirim dan per chat.
+type Cooldown struct {
+	PerUser RateLimit
+	PerChat RateLimit
+}
+
+// scanCooldownName adalah nama batas untuk pemindaian link otomatis, yang bukan perintah
+// tetapi memanggil Gemini sehingga paling mahal dari semua fitur bot.
+const scanCooldownName = "scan"
+
+// defaultScanCooldown adalah batas pemindaian link lewat Gemini. Link yang melebihi batas
+// tetap diperiksa dengan heuristik, tanpa memakai kuota Gemini.
+var defaultScanCooldown = Cooldown{
+	PerUser: RateLimit{Count: 3, Per: time.Minute},
+	PerChat: RateLimit{Count: 10, Per: time.Minute},
+}
+
+// cooldownOverride adalah satu entri COMMAND_COOLDOWNS. PerChat hanya dipakai jika hasChat true;
+// selain itu batas per chat perintah tersebut tidak diubah.
+type cooldownOverride struct {
+	perUser RateLimit
+	perChat RateLimit
+	hasChat bool
+}
+
+func (o cooldownOverride) apply(base Cooldown) Cooldown {
+	base.PerUser = o.perUser
+	if o.hasChat {
+		base.PerChat = o.perChat
+	}
+	return base
+}
+
+// parseCooldownOverrides membaca format "nama=<per-user>[:<per-chat>],...", misal "scan=1/30s,afk=2/1m:5/1m".
+func parseCooldownOverrides(s string) (map[string]cooldownOverride, error) {
+	overrides := make(map[string]cooldownOverride)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("entri %q tidak valid, gunakan nama=<per-user>[:<per-chat>]", entry)
+		}
+		userStr, chatStr, hasChat := strings.Cut(value, ":")
+		var o cooldownOverride
+		var err error
+		if o.perUser, err = parseRateLimit(userStr); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if hasChat {
+			if o.perChat, err = parseRateLimit(chatStr); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			o.hasChat = true
+		}
+		overrides[strings.ToLower(strings.TrimSpace(name))] = o
+	}
+	return overrides, nil
+}
+
+// applyCooldownOverrides memasang COMMAND_COOLDOWNS ke perintah yang sudah terdaftar.
+// Nama perintah yang tidak dikenal dianggap salah konfigurasi.
+func (h *Handler) applyCooldownOverrides(overrides map[string]cooldownOverride) error {
+	for name, o := range overrides {
+		if name == scanCooldownName {
+			h.scanCooldown = o.apply(h.scanCooldown)
+			continue
+		}
+		cmd, ok := h.lookup(name)
+		if !ok {
+			return fmt.Errorf("perintah %q tidak dikenal", name)
+		}
+		base := h.defaultCooldown
+		if cmd.Cooldown != nil {
+			base = *cmd.Cooldown
+		}
+		cooldown := o.apply(base)
+		cmd.Cooldown = &cooldown
+	}
+	return nil
+}
+
+// rateLimiter menyimpan token bucket untuk setiap kombinasi perintah dan pengirim/chat.
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+	// notifiedUntil mencegah balasan "coba lagi" dikirim lebih dari sekali per jendela tunggu.
+	notifiedUntil time.Time
+}
+
+// maxBuckets adalah batas jumlah bucket sebelum bucket yang sudah penuh kembali dibersihkan.
+const maxBuckets = 4096
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*bucket)}
+}
+
+// refill menambahkan token sesuai waktu yang berlalu sejak pemakaian terakhir.
+func (b *bucket) refill(now time.Time) {
+	rate := float64(b.limit.Count) / b.limit.Per.Seconds()
+	b.tokens = math.Min(float64(b.limit.Count), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+}
+
+// wait mengembalikan waktu sampai satu token tersedia.
+func (b *bucket) wait() time.Duration {
+	rate := float64(b.limit.Count) / b.limit.Per.Seconds()
+	return time.Duration((1 - b.tokens) / rate * float64(time.Second))
+}
+
+// allow mengambil satu token dari setiap bucket secara atomik. Jika salah satu habis,
+// tidak ada token yang diambil dan dikembalikan lama waktu tunggu terpanjang.
+// notify bernilai true hanya untuk penolakan pertama dalam satu jendela tunggu.
+func (l *rateLimiter) allow(now time.Time, limits map[string]RateLimit) (ok bool, wait time.Duration, notify bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.buckets) > maxBuckets {
+		l.sweep(now)
+	}
+
+	var denied []*bucket
+	var active []*bucket
+	for key, limit := range limits {
+		if limit.unlimited() {
+			continue
+		}
+		b, exists := l.buckets[key]
+		if !exists || b.limit != limit {
+			b = &bucket{limit: limit, tokens: float64(limit.Count), last: now}
+			l.buckets[key] = b
+		}
+		b.refill(now)
+		if b.tokens < 1 {
+			denied = append(denied, b)
+			wait = max(wait, b.wait())
+		}
+		active = append(active, b)
+	}
+
+	if len(denied) == 0 {
+		for _, b := range active {
+			b.tokens--
+		}
+		return true, 0, false
+	}
+
+	notify = true
+	for _, b := range denied {
+		if now.Before(b.notifiedUntil) {
+			notify = false
+		}
+		b.notifiedUntil = now.Add(b.wait())
+	}
+	return false, wait, notify
+}
+
+// sweep menghapus bucket yang sudah terisi penuh karena tidak lagi membawa informasi.
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Count) && now.After(b.notifiedUntil) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// cooldownMiddleware menolak perintah yang melebihi batas pemakaian, kecuali dari Owner.
+// Balasan "coba lagi" dikirim paling banyak sekali per jendela tunggu agar tidak ikut membanjiri chat.
+func (h *Handler) cooldownMiddleware(next CommandFunc) CommandFunc {
+	return func(c Command) (whatsmeow.SendResponse, error) {
+		sender := c.evt.Info.Sender.ToNonAD()
+		chat := c.evt.Info.Chat
+		if h.getUserLevel(sender, chat) >= int(Owner) {
+			return next(c)
+		}
+
+		cooldown := h.defaultCooldown
+		if c.Cooldown != nil {
+			cooldown = *c.Cooldown
+		}
+
+		ok, wait, notify := h.limiter.allow(time.Now(), map[string]RateLimit{
+			"user:" + c.Name + ":" + sender.String(): cooldown.PerUser,
+			"chat:" + c.Name + ":" + chat.String():   cooldown.PerChat,
+		})
+		if ok {
+			return next(c)
+		}
+
+		h.logger.Infof("Command '%s' from %s rate limited for %s", c.Name, sender, wait)
+		if !notify {
+			return whatsmeow.SendResponse{}, nil
+		}
+		seconds := int(math.Ceil(wait.Seconds()))
+		return h.sendReply(c, fmt.Sprintf("⏳ Terlalu cepat. Coba lagi `%s%s` dalam %d detik.", h.prefix, c.Name, seconds))
+	}
+}
+
+// allowScan memeriksa batas pemindaian link lewat Gemini untuk pengirim dan chat pesan.
+// Pesan owner tidak dibatasi, sama seperti perintah.
+func (h *Handler) allowScan(evt *events.Message) bool {
+	sender := evt.Info.Sender.ToNonAD()
+	chat := evt.Info.Chat
+	if evt.Info.IsFromMe || h.getUserLevel(sender, chat) >= int(Owner) {
+		return true
+	}
+	ok, _, _ := h.limiter.allow(time.Now(), map[string]RateLimit{
+		"user:" + scanCooldownName + ":" + sender.String(): h.scanCooldown.PerUser,
+		"chat:" + scanCooldownName + ":" + chat.String():   h.scanCooldown.PerChat,
+	})
+	return ok
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Satr10/wa-userbot/internal/ai"
+	"github.com/Satr10/wa-userbot/internal/domainlist"
+	"github.com/Satr10/wa-userbot/internal/urlcache"
+	"go.mau.fi/whatsmeow"
+)
+
+// domainListCommand membuat Handler untuk .urlallow dan .urlblock yang mengelola satu daftar domain.
+func (h *Handler) domainListCommand(list string) CommandFunc {
+	return func(c Command) (whatsmeow.SendResponse, error) {
+		action := strings.ToLower(c.parsed.String("aksi"))
+		// Ambil teks mentah agar regex yang mengandung spasi tetap utuh.
+		pattern := restAfterFirst(c.rawArgs)
+
+		switch action {
+		case "list":
+			return h.sendReply(c, h.renderDomainList(list))
+
+		case "add", "del":
+			if pattern == "" {
+				return whatsmeow.SendResponse{}, userErrorf("Penggunaan: %s", h.usageLine(&c))
+			}
+			if action == "add" {
+				normalized, err := h.domains.Add(c.ctx, list, pattern)
+				if err != nil {
+					return whatsmeow.SendResponse{}, &UserError{Msg: "Gagal menambahkan pola", Err: err}
+				}
+				return h.sendReply(c, fmt.Sprintf("Pola `%s` ditambahkan ke daftar *%s*.", normalized, list))
+			}
+
+			found, err := h.domains.Remove(c.ctx, list, pattern)
+			if err != nil {
+				return whatsmeow.SendResponse{}, fmt.Errorf("gagal menghapus pola %s dari daftar %s: %w", pattern, list, err)
+			}
+			if !found {
+				return whatsmeow.SendResponse{}, userErrorf("Pola %s tidak ada di daftar %s", pattern, list)
+			}
+			return h.sendReply(c, fmt.Sprintf("Pola `%s` dihapus dari daftar *%s*.", pattern, list))
+
+		default:
+			return whatsmeow.SendResponse{}, userErrorf("Aksi %q tidak dikenal, gunakan add, del atau list", action)
+		}
+	}
+}
+
+// renderDomainList merakit isi satu daftar domain.
+func (h *Handler) renderDomainList(list string) string {
+	var sb strings.Builder
+	if list == domainlist.Block {
+		sb.WriteString("⛔ *DAFTAR BLOKIR DOMAIN*\n")
+	} else {
+		sb.WriteString("✅ *DAFTAR IZIN DOMAIN*\n")
+	}
+	sb.WriteString("━━━━━━━━━━━━━━━━━━━━\n")
+
+	patterns := h.domains.Rules(list)
+	if len(patterns) == 0 {
+		sb.WriteString("\n_Daftar masih kosong._")
+		return sb.String()
+	}
+	for _, pattern := range patterns {
+		sb.WriteString(fmt.Sprintf("• `%s`\n", pattern))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// blockedVerdict membuat hasil scan DANGEROUS untuk URL yang cocok dengan daftar blokir,
+// tanpa memanggil Gemini.
+func blockedVerdict(url string, rule domainlist.Rule) *ai.URLScanResult {
+	result := &ai.URLScanResult{
+		URL:             url,
+		InvestigationID: urlcache.Key(url),
+		Status:          "COMPLETED",
+		Reasoning:       "domain cocok dengan daftar blokir owner",
+	}
+	result.FinalVerdict.Category = "DANGEROUS"
+	result.FinalVerdict.Explanation = fmt.Sprintf("Domain pada link ini diblokir oleh owner (aturan %s). Jangan buka link ini dan jangan masukkan data apa pun.", rule.Pattern)
+	result.FinalVerdict.ConfidenceScore = 1
+	return result
+}
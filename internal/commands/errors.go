@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// UserError adalah kesalahan akibat input atau kondisi dari pengguna.
+// Pesannya aman ditampilkan langsung ke chat.
+type UserError struct {
+	Msg string
+	Err error
+}
+
+func (e *UserError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Msg, e.Err)
+	}
+	return e.Msg
+}
+
+func (e *UserError) Unwrap() error {
+	return e.Err
+}
+
+// userErrorf membuat UserError dengan pesan terformat.
+func userErrorf(format string, a ...any) error {
+	return &UserError{Msg: fmt.Sprintf(format, a...)}
+}
+
+// panicError membungkus panic dari Handler beserta stack trace-nya.
+type panicError struct {
+	value any
+	stack []byte
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+const (
+	internalErrorReply = "⚠️ Maaf, terjadi kesalahan saat menjalankan perintah ini. Owner sudah diberi tahu."
+	// maxStackReport membatasi panjang stack trace yang dikirim ke DM owner.
+	maxStackReport = 3000
+)
+
+// recoveryMiddleware menangkap panic per pemanggilan agar satu perintah tidak menghentikan seluruh bot.
+func (h *Handler) recoveryMiddleware(next CommandFunc) CommandFunc {
+	return func(c Command) (resp whatsmeow.SendResponse, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &panicError{value: r, stack: debug.Stack()}
+			}
+		}()
+		return next(c)
+	}
+}
+
+// errorReportMiddleware mengklasifikasikan error dari Handler. UserError dibalas apa adanya,
+// sedangkan error internal dibalas dengan pesan umum dan detailnya dikirim ke DM owner.
+func (h *Handler) errorReportMiddleware(next CommandFunc) CommandFunc {
+	return func(c Command) (whatsmeow.SendResponse, error) {
+		resp, err := next(c)
+		if err == nil {
+			return resp, nil
+		}
+
+		var userErr *UserError
+		if errors.As(err, &userErr) {
+			h.logger.Infof("Command '%s' rejected: %v", c.Name, err)
+			if _, sendErr := h.sendReply(c, "❌ "+userErr.Error()); sendErr != nil {
+				h.logger.Errorf("error sending user error reply, err: %v", sendErr)
+			}
+			return resp, err
+		}
+
+		h.logger.Errorf("Error executing command '%s' (message %s): %v", c.Name, c.evt.Info.ID, err)
+		if _, sendErr := h.sendReply(c, internalErrorReply); sendErr != nil {
+			h.logger.Errorf("error sending internal error reply, err: %v", sendErr)
+		}
+		h.reportToOwner(c, err)
+		return resp, err
+	}
+}
+
+// reportToOwner mengirim detail error, termasuk stack trace untuk panic, ke DM owner.
+func (h *Handler) reportToOwner(c Command, err error) {
+	if len(h.owners) == 0 {
+		h.logger.Warnf("cannot report error to owner, OWNER_ID is not set or invalid")
+		return
+	}
+	// Kirim ke bentuk kanonik (nomor telepon) agar masuk ke DM yang biasa dipakai owner.
+	ownerJID := h.identity.Canonical(c.ctx, h.owners[0])
+
+	var sb strings.Builder
+	sb.WriteString("🚨 *LAPORAN ERROR PERINTAH*\n")
+	sb.WriteString("━━━━━━━━━━━━━━━━━━━━\n")
+	sb.WriteString(fmt.Sprintf("*Perintah:* `%s%s`\n", h.prefix, c.Name))
+	sb.WriteString(fmt.Sprintf("*Pengirim:* %s\n", c.evt.Info.Sender))
+	sb.WriteString(fmt.Sprintf("*Chat:* %s\n", c.evt.Info.Chat))
+	sb.WriteString(fmt.Sprintf("*Message ID:* %s\n", c.evt.Info.ID))
+	sb.WriteString(fmt.Sprintf("*Args:* %q\n\n", c.args))
+	sb.WriteString(fmt.Sprintf("*Error:*\n```%v```", err))
+
+	var panicErr *panicError
+	if errors.As(err, &panicErr) {
+		stack := string(panicErr.stack)
+		if len(stack) > maxStackReport {
+			stack = stack[:maxStackReport] + "\n..."
+		}
+		sb.WriteString(fmt.Sprintf("\n\n*Stack:*\n```%s```", stack))
+	}
+
+	if _, sendErr := SendTextToJID(c.ctx, c.client, ownerJID, sb.String()); sendErr != nil {
+		h.logger.Errorf("error sending error report to owner, err: %v", sendErr)
+	}
+}
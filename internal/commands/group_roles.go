@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// groupRoleTTL adalah batas umur cache peran grup, sebagai cadangan jika event GroupInfo terlewat.
+const groupRoleTTL = 10 * time.Minute
+
+// groupRoleCache menyimpan peran admin per grup agar pengecekan izin tidak selalu memanggil server.
+type groupRoleCache struct {
+	mu     sync.RWMutex
+	groups map[types.JID]groupRoles
+}
+
+type groupRoles struct {
+	// levels berisi GroupAdmin atau SuperAdmin, dikunci dengan user JID (phone maupun LID).
+	levels  map[string]PermissionLevel
+	fetched time.Time
+}
+
+func newGroupRoleCache() *groupRoleCache {
+	return &groupRoleCache{groups: make(map[types.JID]groupRoles)}
+}
+
+func (c *groupRoleCache) get(group types.JID) (groupRoles, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	roles, ok := c.groups[group]
+	if !ok || time.Since(roles.fetched) > groupRoleTTL {
+		return groupRoles{}, false
+	}
+	return roles, true
+}
+
+func (c *groupRoleCache) set(group types.JID, roles groupRoles) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.groups[group] = roles
+}
+
+func (c *groupRoleCache) invalidate(group types.JID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.groups, group)
+}
+
+// groupLevel mengembalikan level admin pengirim di grup, atau Everyone jika bukan admin
+// atau info grup gagal diambil.
+func (h *Handler) groupLevel(senderJID, groupJID types.JID) PermissionLevel {
+	roles, ok := h.groupRoles.get(groupJID)
+	if !ok {
+		info, err := h.client.GetGroupInfo(groupJID)
+		if err != nil {
+			h.logger.Warnf("error getting group info for %s, err: %v", groupJID, err)
+			return Everyone
+		}
+
+		roles = groupRoles{levels: make(map[string]PermissionLevel), fetched: time.Now()}
+		for _, p := range info.Participants {
+			var level PermissionLevel
+			switch {
+			case p.IsSuperAdmin:
+				level = SuperAdmin
+			case p.IsAdmin:
+				level = GroupAdmin
+			default:
+				continue
+			}
+			// Peserta bisa muncul sebagai nomor telepon atau LID, simpan keduanya.
+			for _, jid := range []types.JID{p.JID, p.PhoneNumber, p.LID} {
+				if !jid.IsEmpty() {
+					roles.levels[jid.ToNonAD().String()] = level
+				}
+			}
+		}
+		h.groupRoles.set(groupJID, roles)
+	}
+
+	level := Everyone
+	for _, key := range h.identity.Keys(context.Background(), senderJID) {
+		level = max(level, roles.levels[key])
+	}
+	return level
+}
+
+// HandleGroupInfo membuang cache peran grup ketika ada perubahan admin atau anggota.
+func (h *Handler) HandleGroupInfo(evt *events.GroupInfo) {
+	if len(evt.Promote) == 0 && len(evt.Demote) == 0 && len(evt.Join) == 0 && len(evt.Leave) == 0 {
+		return
+	}
+	h.logger.Debugf("Group %s participants changed, invalidating role cache", evt.JID)
+	h.groupRoles.invalidate(evt.JID)
+}
@@ -2,18 +2,26 @@ package commands
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
 
+	"github.com/Satr10/wa-userbot/internal/afk"
 	"github.com/Satr10/wa-userbot/internal/ai"
 	aitools "github.com/Satr10/wa-userbot/internal/ai_tools"
 	"github.com/Satr10/wa-userbot/internal/config"
+	"github.com/Satr10/wa-userbot/internal/domainlist"
+	"github.com/Satr10/wa-userbot/internal/identity"
 	"github.com/Satr10/wa-userbot/internal/permissions"
+	"github.com/Satr10/wa-userbot/internal/threatintel"
+	"github.com/Satr10/wa-userbot/internal/urlcache"
+	"github.com/Satr10/wa-userbot/internal/urlcanon"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
@@ -27,67 +35,263 @@ type CommandFunc func(Command) (whatsmeow.SendResponse, error)
 type Handler struct {
 	client   *whatsmeow.Client
 	registry map[string]*Command // Changed to hold pointers
+	aliases  map[string]string   // alias -> nama utama perintah
 	logger   waLog.Logger
 	prefix   string
 	cfg      config.Config
-	locTime  *time.Location
+	afk      *afk.Manager
 	gemini   *ai.Gemini
 	urlRegex *regexp.Regexp
 	log      *slog.Logger
 	perm     *permissions.Manager
+	urlCache *urlcache.Cache
+	domains  *domainlist.Manager
+	feeds    *threatintel.Manager
+
+	middlewares     []Middleware
+	limiter         *rateLimiter
+	groupRoles      *groupRoleCache
+	identity        *identity.Resolver
+	owners          []types.JID
+	defaultCooldown Cooldown
+	// scanCooldown membatasi pemindaian link lewat Gemini; lihat defaultScanCooldown.
+	scanCooldown Cooldown
+
+	// vipForward, webhookClient dan vipWebhooks dipakai jalur notifikasi darurat untuk kontak VIP saat AFK.
+	vipForward    types.JID
+	webhookClient *http.Client
+	vipWebhooks   chan vipWebhookPayload
 }
 
 // NewHandler creates a new command handler.
-func NewHandler(client *whatsmeow.Client, logger waLog.Logger, config config.Config, permManager *permissions.Manager) (*Handler, error) {
-	loc, err := time.LoadLocation("Asia/Jakarta")
+func NewHandler(client *whatsmeow.Client, logger waLog.Logger, config config.Config, permManager *permissions.Manager, afkManager *afk.Manager, urlCache *urlcache.Cache, domains *domainlist.Manager, feeds *threatintel.Manager) (*Handler, error) {
+	aiTools := aitools.NewTools(config.GSBAPIKey, feeds, strings.Split(config.ProtectedBrands, ","))
+	newGemini, err := ai.NewGemini(context.TODO(), config.GeminiAPIKey, ai.UrlCheckSystemPrompt, aiTools)
 	if err != nil {
-		return nil, fmt.Errorf("gagal memuat lokasi Asia/Jakarta: %w", err)
+		return nil, err
 	}
 
-	aiTools := aitools.NewTools(config.GSBAPIKey)
-	newGemini, err := ai.NewGemini(context.TODO(), config.GeminiAPIKey, ai.UrlCheckSystemPrompt, aiTools)
+	userRate, err := parseRateLimit(config.CommandUserRate)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("COMMAND_USER_RATE: %w", err)
+	}
+	chatRate, err := parseRateLimit(config.CommandChatRate)
+	if err != nil {
+		return nil, fmt.Errorf("COMMAND_CHAT_RATE: %w", err)
+	}
+	cooldowns, err := parseCooldownOverrides(config.CommandCooldowns)
+	if err != nil {
+		return nil, fmt.Errorf("COMMAND_COOLDOWNS: %w", err)
+	}
+
+	var vipForward types.JID
+	if config.AFKVIPForwardTo != "" {
+		vipForward, err = parseJIDArg(config.AFKVIPForwardTo, nil)
+		if err != nil {
+			return nil, fmt.Errorf("AFK_VIP_FORWARD_TO: %w", err)
+		}
 	}
 
 	urlRegex := regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*:(//)?[^\s]*|\b(?:[a-zA-Z0-9-]+\.)+[a-zA-Z]{2,}\b(?:/[^\s]*)?`)
 
 	h := &Handler{
-		client:   client,
-		registry: make(map[string]*Command), // Changed to hold pointers
-		logger:   logger,
-		prefix:   ".",
-		cfg:      config,
-		locTime:  loc,
-		gemini:   newGemini,
-		urlRegex: urlRegex,
-		perm:     permManager,
+		client:     client,
+		registry:   make(map[string]*Command), // Changed to hold pointers
+		aliases:    make(map[string]string),
+		logger:     logger,
+		prefix:     ".",
+		cfg:        config,
+		afk:        afkManager,
+		gemini:     newGemini,
+		urlRegex:   urlRegex,
+		perm:       permManager,
+		urlCache:   urlCache,
+		domains:    domains,
+		feeds:      feeds,
+		limiter:    newRateLimiter(),
+		groupRoles: newGroupRoleCache(),
+		identity:   identity.NewResolver(client, logger),
+		owners:     parseOwners(config),
+		defaultCooldown: Cooldown{
+			PerUser: userRate,
+			PerChat: chatRate,
+		},
+		scanCooldown:  defaultScanCooldown,
+		vipForward:    vipForward,
+		webhookClient: &http.Client{Timeout: webhookTimeout},
+		vipWebhooks:   make(chan vipWebhookPayload, vipWebhookQueueSize),
+	}
+	if config.AFKVIPWebhook != "" {
+		go h.runVIPWebhooks()
 	}
 
+	// Urutan penting: laporan error dan recovery paling luar agar panic di middleware lain ikut tertangkap,
+	// lalu izin dicek sebelum apa pun agar pengirim tanpa izin tidak mendapat balasan.
+	h.Use(
+		h.errorReportMiddleware,
+		h.recoveryMiddleware,
+		h.permissionMiddleware,
+		h.cooldownMiddleware,
+		h.argsMiddleware,
+		h.loggingMiddleware,
+	)
 	h.registerCommands()
+	if err := h.applyCooldownOverrides(cooldowns); err != nil {
+		return nil, fmt.Errorf("COMMAND_COOLDOWNS: %w", err)
+	}
 
 	return h, nil
 }
 
 // registerCommands initializes and registers all commands.
 func (h *Handler) registerCommands() {
-	h.registry["ping"] = &Command{
+	h.register(&Command{
+		Name:            "help",
+		Aliases:         []string{"menu", "h"},
+		Summary:         "Tampilkan daftar perintah atau detail satu perintah",
+		Examples:        []string{"", "ping"},
+		Args:            []ArgSpec{{Name: "perintah", Optional: true}},
+		Category:        CategoryGeneral,
+		PermissionLevel: Everyone,
+		Handler:         h.HelpCommand,
+	})
+	h.register(&Command{
+		Name:            "ping",
+		Summary:         "Cek apakah bot aktif",
+		Category:        CategoryGeneral,
 		PermissionLevel: Everyone,
 		Handler:         h.PingCommand,
-	}
-	h.registry["edit"] = &Command{
+	})
+	h.register(&Command{
+		Name:            "edit",
+		Summary:         "Uji coba fitur edit pesan",
+		Category:        CategoryDebug,
 		PermissionLevel: Everyone,
 		Handler:         h.EditMsgTest,
-	}
+	})
 	// Perintah manajemen tetap hanya untuk Owner
-	h.registry["addgroup"] = &Command{
+	h.register(&Command{
+		Name:            "addgroup",
+		Summary:         "Izinkan grup ini memakai fitur bot",
+		Category:        CategoryManagement,
 		PermissionLevel: Owner,
 		Handler:         h.AddGroupCommand,
-	}
-	h.registry["delgroup"] = &Command{
+	})
+	h.register(&Command{
+		Name:            "delgroup",
+		Summary:         "Cabut izin grup ini",
+		Category:        CategoryManagement,
 		PermissionLevel: Owner,
 		Handler:         h.DelGroupCommand,
+	})
+	h.register(&Command{
+		Name:            "adduser",
+		Summary:         "Izinkan pengguna memakai fitur bot di chat mana pun",
+		Usage:           "<@user|nomor...> (atau reply pesannya)",
+		Examples:        []string{"@user", "081234567890", "+62 812-3456-7890"},
+		Category:        CategoryManagement,
+		Args:            []ArgSpec{{Name: "user", Type: ArgJID, Optional: true, Variadic: true}},
+		PermissionLevel: Owner,
+		Handler:         h.AddUserCommand,
+	})
+	h.register(&Command{
+		Name:            "deluser",
+		Summary:         "Cabut izin pengguna",
+		Usage:           "<@user|nomor...> (atau reply pesannya)",
+		Examples:        []string{"@user", "081234567890"},
+		Category:        CategoryManagement,
+		Args:            []ArgSpec{{Name: "user", Type: ArgJID, Optional: true, Variadic: true}},
+		PermissionLevel: Owner,
+		Handler:         h.DelUserCommand,
+	})
+	h.register(&Command{
+		Name:            "listallowed",
+		Aliases:         []string{"allowed"},
+		Summary:         "Tampilkan semua pengguna dan grup yang diizinkan",
+		Category:        CategoryManagement,
+		PermissionLevel: Owner,
+		Handler:         h.ListAllowedCommand,
+	})
+	h.register(&Command{
+		Name:            "role",
+		Summary:         "Kelola role pengguna (moderator, trusted, banned, ...)",
+		Usage:           "add|del <@user> <role> [--group] | list [@user]",
+		Examples:        []string{"add @user moderator", "add @user trusted --group", "del @user banned", "list"},
+		Category:        CategoryManagement,
+		Args:            []ArgSpec{{Name: "aksi"}, {Name: "user", Type: ArgJID, Optional: true}, {Name: "role", Optional: true}},
+		Flags:           []FlagSpec{{Name: "group", Type: ArgBool}},
+		PermissionLevel: Owner,
+		Handler:         h.RoleCommand,
+	})
+	h.register(&Command{
+		Name:            "grant",
+		Summary:         "Izinkan atau larang pengguna/role menjalankan perintah tertentu",
+		Usage:           "<perintah> <@user|role> [--deny]",
+		Examples:        []string{"ping @user", "addgroup moderator", "ping banned --deny"},
+		Category:        CategoryManagement,
+		Args:            []ArgSpec{{Name: "perintah"}, {Name: "target"}},
+		Flags:           []FlagSpec{{Name: "deny", Type: ArgBool}},
+		PermissionLevel: Owner,
+		Handler:         h.GrantCommand,
+	})
+	h.register(&Command{
+		Name:            "revoke",
+		Summary:         "Hapus grant perintah untuk pengguna/role",
+		Usage:           "<perintah> <@user|role>",
+		Examples:        []string{"ping @user"},
+		Category:        CategoryManagement,
+		Args:            []ArgSpec{{Name: "perintah"}, {Name: "target"}},
+		PermissionLevel: Owner,
+		Handler:         h.RevokeCommand,
+	})
+	h.register(&Command{
+		Name:     "afk",
+		Summary:  "Nyalakan AFK manual, atau lihat/ubah jadwal, zona waktu dan pesan AFK",
+		Usage:    "[<alasan>|off|status|reload|schedule|timezone|renotify|message] [nilai]",
+		Examples: []string{"makan siang dulu", "off", "schedule mon-fri 22:00-07:00; sat,sun 00:00-09:00", "timezone Asia/Makassar", "renotify 2h", "message Halo {sender}, {name} sedang istirahat sampai {until}."},
+		Category: CategoryGeneral,
+		Args:     []ArgSpec{{Name: "aksi", Optional: true}, {Name: "nilai", Optional: true, Variadic: true}},
+		// Template pesan bisa berisi tanda kutip seperti "Jum'at", jadi jangan diproses sebagai kutipan.
+		RawArgs:         true,
+		PermissionLevel: Owner,
+		Handler:         h.AFKCommand,
+	})
+	for _, list := range []string{domainlist.Allow, domainlist.Block} {
+		h.register(&Command{
+			Name:     "url" + list,
+			Summary:  fmt.Sprintf("Kelola daftar %s domain untuk scan URL", list),
+			Usage:    "add|del <domain|*.domain|/regex/> | list",
+			Examples: []string{"add *.ourcorp.id", "add google.com", `add /^login-.*\.xyz$/`, "del google.com", "list"},
+			Category: CategoryManagement,
+			Args:     []ArgSpec{{Name: "aksi"}, {Name: "pola", Optional: true, Variadic: true}},
+			// Regex bisa berisi backslash dan tanda kutip, jadi jangan diproses sebagai escape.
+			RawArgs:         true,
+			PermissionLevel: Owner,
+			Handler:         h.domainListCommand(list),
+		})
 	}
+	h.register(&Command{
+		Name:     "afklist",
+		Summary:  "Atur pengguna/grup yang tidak dibalas AFK (ignore) atau mendapat jalur darurat (vip)",
+		Usage:    "ignore|vip|del [@user...] [--here] | list",
+		Examples: []string{"vip @user", "ignore --here", "del 081234567890", "list"},
+		Category: CategoryManagement,
+		Args:     []ArgSpec{{Name: "aksi"}, {Name: "target", Type: ArgJID, Optional: true, Variadic: true}},
+		// --here menargetkan chat tempat perintah dijalankan, misal grup yang ingin di-mute.
+		Flags:           []FlagSpec{{Name: "here", Type: ArgBool}},
+		PermissionLevel: Owner,
+		Handler:         h.AFKListCommand,
+	})
+	h.register(&Command{
+		Name:            "feeds",
+		Summary:         "Lihat status feed phishing/malware atau muat ulang sekarang",
+		Usage:           "[reload]",
+		Examples:        []string{"", "reload"},
+		Category:        CategoryManagement,
+		Args:            []ArgSpec{{Name: "aksi", Optional: true}},
+		PermissionLevel: Owner,
+		Handler:         h.ThreatFeedsCommand,
+	})
 
 	// Register other commands here in the future
 	h.logger.Infof("Registered %d commands", len(h.registry))
@@ -95,13 +299,37 @@ func (h *Handler) registerCommands() {
 
 func (h *Handler) checkPermission(senderJID types.JID, chatJID types.JID, command *Command) bool {
 	userLevel := h.getUserLevel(senderJID, chatJID)
+	if userLevel >= int(Owner) {
+		return true
+	}
+
+	// Semua identitas pengirim (nomor telepon dan LID) dicocokkan dengan data izin.
+	keys := h.identity.Keys(context.Background(), senderJID)
+
+	// Grant per perintah menimpa PermissionLevel default, baik untuk pengguna maupun role-nya.
+	roles := h.perm.UserRoles(groupIDOf(chatJID), keys...)
+	var subjects []string
+	for _, key := range keys {
+		subjects = append(subjects, permissions.UserSubject(key))
+	}
+	for _, role := range roles {
+		subjects = append(subjects, permissions.RoleSubject(role))
+	}
+	if allow, found := h.perm.CheckGrant(command.Name, subjects...); found {
+		return allow
+	}
+
+	if slices.Contains(roles, permissions.RoleBanned) {
+		return false
+	}
+
 	if userLevel >= int(command.PermissionLevel) {
 		return true
 	}
 
 	if command.PermissionLevel == CertainChat {
 		// Gunakan manajer izin yang baru
-		if h.perm.IsGroupAllowed(chatJID.String()) || h.perm.IsUserAllowed(senderJID.String()) {
+		if h.perm.IsGroupAllowed(chatJID.String()) || slices.ContainsFunc(keys, h.perm.IsUserAllowed) {
 			return true
 		}
 	}
@@ -109,20 +337,65 @@ func (h *Handler) checkPermission(senderJID types.JID, chatJID types.JID, comman
 	return false
 }
 
+// parseOwners membaca OWNER_ID dan OWNER_LID dari config. Nilai yang kosong atau tidak valid dilewati.
+func parseOwners(cfg config.Config) []types.JID {
+	var owners []types.JID
+	for _, raw := range []string{cfg.OwnerID, cfg.OwnerLID} {
+		if raw == "" {
+			continue
+		}
+		jid, err := types.ParseJID(raw)
+		if err != nil {
+			continue
+		}
+		owners = append(owners, identity.Normalize(jid))
+	}
+	return owners
+}
+
+// roleLevels memetakan role bawaan ke level izin yang setara.
+var roleLevels = map[string]PermissionLevel{
+	permissions.RoleModerator: GroupAdmin,
+	permissions.RoleTrusted:   CertainChat,
+}
+
 func (h *Handler) getUserLevel(senderJID types.JID, chatJID types.JID) int {
-	// Ambil OwnerID dari config
-	if senderJID.String() == h.cfg.OwnerID || senderJID.String() == h.cfg.OwnerLID {
+	ctx := context.Background()
+	if h.isOwner(ctx, senderJID) {
 		return int(Owner)
 	}
 
-	//TODO: TAMBAHKAN UNTUK CEK APAKAH USER ADALAH ADMIN GRUP
+	level := Everyone
+
+	// Di grup, admin dan pembuat grup mendapat level sesuai perannya.
+	if chatJID.Server == types.GroupServer {
+		level = h.groupLevel(senderJID, chatJID)
+	}
+
+	for _, role := range h.perm.UserRoles(groupIDOf(chatJID), h.identity.Keys(ctx, senderJID)...) {
+		level = max(level, roleLevels[role])
+	}
+
+	return int(level)
+}
+
+// isOwner memeriksa apakah jid adalah owner dari config, lewat nomor telepon maupun LID.
+func (h *Handler) isOwner(ctx context.Context, jid types.JID) bool {
+	return h.identity.MatchesAny(ctx, jid, h.owners...)
+}
 
-	return int(Everyone)
+// groupIDOf mengembalikan ID grup untuk pencarian role per grup, atau "" jika chat bukan grup.
+func groupIDOf(chatJID types.JID) string {
+	if chatJID.Server != types.GroupServer {
+		return ""
+	}
+	return chatJID.String()
 }
 
 // HandleEvent processes incoming message events to check for commands.
 func (h *Handler) HandleEvent(evt *events.Message) {
 	msgText := ""
+	supported := true
 	if evt.Message.GetConversation() != "" {
 		msgText = evt.Message.GetConversation()
 	} else if evt.Message.ExtendedTextMessage != nil && evt.Message.ExtendedTextMessage.Text != nil {
@@ -130,10 +403,22 @@ func (h *Handler) HandleEvent(evt *events.Message) {
 	} else if evt.Message.ImageMessage != nil {
 		h.logger.Infof("Image Message Retrieved")
 	} else {
+		supported = false
+	}
+	trimmedText := strings.TrimSpace(msgText)
+
+	// Pesan apa pun dari owner (teks, stiker, voice note, reaksi, ...) berarti owner sudah kembali
+	// dari AFK manual, jadi dicek sebelum filter jenis pesan. Perintah dikecualikan agar
+	// ".afk <alasan>" tidak langsung membatalkan dirinya sendiri, begitu juga protocol message
+	// yang dikirim otomatis oleh perangkat owner.
+	if evt.Info.IsFromMe && !strings.HasPrefix(trimmedText, h.prefix) && evt.Message.GetProtocolMessage() == nil {
+		// Error sudah dicatat oleh endManualAFK; AFK tetap aktif dan dicoba lagi pada pesan berikutnya.
+		_, _ = h.endManualAFK(context.Background())
+	}
+	if !supported {
 		return
 	}
 
-	trimmedText := strings.TrimSpace(msgText)
 	if strings.HasPrefix(trimmedText, h.prefix) {
 		h.HandleCommand(trimmedText, evt)
 		return // It's a command, so we stop further processing
@@ -143,130 +428,105 @@ func (h *Handler) HandleEvent(evt *events.Message) {
 }
 
 func (h *Handler) HandleCommand(trimmedText string, evt *events.Message) {
-	parts := strings.Fields(trimmedText)
-	if len(parts) == 0 {
+	// Pisahkan nama perintah dari sisa teks; sisa teks diparsing oleh argsMiddleware.
+	nameEnd := strings.IndexFunc(trimmedText, unicode.IsSpace)
+	if nameEnd < 0 {
+		nameEnd = len(trimmedText)
+	}
+	commandName := strings.ToLower(strings.TrimPrefix(trimmedText[:nameEnd], h.prefix))
+	if commandName == "" {
 		return
 	}
 
-	commandName := strings.ToLower(strings.TrimPrefix(parts[0], h.prefix))
-	args := parts[1:]
-
-	command, exists := h.registry[commandName] // Use the handler's registry
+	command, exists := h.lookup(commandName)
 	if !exists {
 		h.logger.Infof("Unknown command received: %s", commandName)
 		return
 	}
-	fmt.Println(evt.Info.Sender.ToNonAD())
-
-	if !h.checkPermission(evt.Info.Sender.ToNonAD(), evt.Info.Chat, command) {
-		return
-	}
 
-	h.logger.Infof("Executing command '%s' from %s with args: %v", commandName, evt.Info.Sender, args)
+	// Salin definisi perintah lalu isi konteks pemanggilannya.
+	c := *command
+	c.ctx = context.Background()
+	c.evt = evt
+	c.client = h.client
+	c.rawArgs = trimmedText[nameEnd:]
 
-	go func() {
-		ctx := context.Background()
-		_, err := command.Handler(Command{ctx: ctx, evt: evt, client: h.client, args: args})
-		if err != nil {
-			h.logger.Errorf("Error executing command '%s': %v", commandName, err)
-			SendTextMessage(TextMessage{
-				ctx:    ctx,
-				client: h.client,
-				evt:    evt,
-				text:   fmt.Sprintf("err: %v", err),
-			})
-		}
-	}()
-}
-
-func (h *Handler) MessageHandler(evt *events.Message, msgText string) {
-	h.AFKHandler(evt)
-	h.UrlScan(evt, msgText)
-}
-
-// AFKHandler menangani logika untuk membalas pesan secara otomatis saat di luar jam kerja.
-func (h *Handler) AFKHandler(evt *events.Message) {
-	// Guard Clause: Abaikan pesan dari diri sendiri.
-	if evt.Info.IsFromMe {
-		return
-	}
-
-	// Periksa apakah saat ini dalam periode AFK.
-	now := time.Now().In(h.locTime)
-	if !h.isWithinAFKHours(now) {
-		return
-	}
-
-	// Tentukan apakah bot harus membalas:
-	// - Selalu balas di Direct Message (DM).
-	// - Di grup, hanya balas jika di-mention.
-	isDM := !evt.Info.IsGroup
-	isMentioned := evt.Info.IsGroup && h.isBotMentioned(evt)
-
-	if !isDM && !isMentioned {
-		return
-	}
-
-	// Jika semua kondisi terpenuhi, kirim pesan AFK.
-	h.sendAFKMessage(evt)
+	go h.chain(command)(c)
 }
 
-// isBotMentioned memeriksa apakah JID bot ada dalam daftar mention pesan.
-func (h *Handler) isBotMentioned(evt *events.Message) bool {
-	extMsg := evt.Message.ExtendedTextMessage
-	if extMsg == nil || extMsg.ContextInfo == nil {
-		return false
-	}
-
-	mentionedJIDs := extMsg.GetContextInfo().GetMentionedJID()
-	if len(mentionedJIDs) == 0 {
-		return false
-	}
-
-	// Ambil JID bot sekali saja untuk perbandingan.
-	myJID := h.client.Store.GetJID().ToNonAD().String()
-	myLID := h.client.Store.LID.String()
-
-	// Gunakan perulangan untuk efisiensi jika daftar mention besar.
-	// slices.Contains melakukan hal serupa di baliknya.
-	for _, mentioned := range mentionedJIDs {
-		if mentioned == myJID || mentioned == myLID {
-			return true
-		}
+// replyUsageError membalas dengan alasan kesalahan input beserta format penggunaan perintah.
+func (h *Handler) replyUsageError(c Command, err error) (whatsmeow.SendResponse, error) {
+	if !errors.Is(err, ErrUsage) {
+		// Bukan kesalahan input (misal default flag tidak valid), serahkan ke errorReportMiddleware.
+		return whatsmeow.SendResponse{}, err
 	}
-
-	return false
+	msg := fmt.Sprintf("❌ %v\n\n*Penggunaan:* `%s`\nKetik `%shelp %s` untuk detail.", err, h.usageLine(&c), h.prefix, c.Name)
+	return h.sendReply(c, msg)
 }
 
-// isWithinAFKHours memeriksa apakah waktu yang diberikan berada dalam rentang AFK (22:00 - 07:00).
-func (h *Handler) isWithinAFKHours(t time.Time) bool {
-	// Logika ini menangani kasus semalam (misal: 22:00 - 07:00 keesokan harinya)
-	hour := t.Hour()
-	return hour >= 22 || hour < 7
+func (h *Handler) MessageHandler(evt *events.Message, msgText string) {
+	h.AFKHandler(evt, msgText)
+	h.UrlScan(evt, msgText)
 }
 
-// sendAFKMessage merakit dan mengirimkan pesan balasan AFK.
-func (h *Handler) sendAFKMessage(evt *events.Message) {
-	rawText := fmt.Sprintf("Hai! 👋 Terima kasih atas pesannya. Saat ini saya sedang dalam mode istirahat (22.00 - 07.00) dan semua notifikasi sedang nonaktif. Pesan Anda sudah diterima dengan baik dan akan saya balas besok pagi ya. Terima kasih!%s", Footer)
+func (h *Handler) UrlScan(evt *events.Message, msgText string) {
+	if evt.Info.IsFromMe || h.perm.IsGroupAllowed(evt.Info.Chat.ToNonAD().String()) {
+		seen := make(map[string]bool)
+		for _, match := range h.urlRegex.FindAllString(msgText, -1) {
+			// Samakan variasi penulisan link (huruf besar, parameter pelacakan, tanda baca di akhir, dsb.)
+			// sebelum di-hash agar link yang sama hanya dipindai sekali.
+			url, err := urlcanon.Canonicalize(match)
+			if err != nil {
+				h.logger.Debugf("skipping unparseable url %q, err: %v", match, err)
+				continue
+			}
+			if seen[url] {
+				continue
+			}
+			seen[url] = true
+
+			// Daftar domain owner dicek sebelum cache dan AI: allow dilewati diam-diam,
+			// block langsung dibalas DANGEROUS.
+			if rule, ok := h.domains.Match(url); ok {
+				if rule.List == domainlist.Allow {
+					h.logger.Debugf("Skipping allowlisted url %s (rule %s)", url, rule.Pattern)
+					continue
+				}
+				ReplyToTextMesssage(TextMessage{
+					ctx:    context.TODO(),
+					client: h.client,
+					evt:    evt,
+					text:   blockedVerdict(url, rule).FormatWhatsAppMessage() + Footer,
+				})
+				continue
+			}
 
-	textMsg := TextMessage{
-		ctx:    context.Background(),
-		evt:    evt,
-		client: h.client,
-		text:   rawText,
-	}
+			// Feed phishing/malware lokal dicek sebelum cache agar indikator baru dari refresh feed
+			// langsung berlaku meskipun link pernah dinyatakan aman.
+			if match, ok := h.feeds.Match(url); ok {
+				ReplyToTextMesssage(TextMessage{
+					ctx:    context.TODO(),
+					client: h.client,
+					evt:    evt,
+					text:   threatFeedVerdict(url, match).FormatWhatsAppMessage() + Footer,
+				})
+				continue
+			}
 
-	if _, err := ReplyToTextMesssage(textMsg); err != nil {
-		h.logger.Errorf("error sending afk message, err: %s", err)
-	}
-}
+			// Link yang sudah pernah diperiksa langsung dijawab dari cache tanpa memanggil Gemini.
+			if cached, err := h.urlCache.Get(context.TODO(), url); err != nil {
+				h.logger.Warnf("error reading url cache for %s, err: %v", url, err)
+			} else if cached != nil {
+				ReplyToTextMesssage(TextMessage{
+					ctx:    context.TODO(),
+					client: h.client,
+					evt:    evt,
+					text:   cached.Result.FormatWhatsAppMessage() + fmt.Sprintf("\n\n_♻️ Hasil cache, diperiksa %s lalu_", formatElapsed(time.Since(cached.CachedAt))) + Footer,
+				})
+				continue
+			}
 
-func (h *Handler) UrlScan(evt *events.Message, msgText string) {
-	if evt.Info.IsFromMe || h.perm.IsGroupAllowed(evt.Info.Chat.ToNonAD().String()) {
-		allUrls := h.urlRegex.FindAll([]byte(msgText), -1)
-		for _, url := range allUrls {
-			hash := sha256.Sum256([]byte(url))
-			id := hex.EncodeToString(hash[:])
+			id := urlcache.Key(url)
 
 			var initialPrompt string
 			if len(url) > 512 {
@@ -275,19 +535,25 @@ func (h *Handler) UrlScan(evt *events.Message, msgText string) {
 				initialPrompt = fmt.Sprintf("Mulai investigasi untuk URL: %s dengan ID: %s", url, id)
 			}
 
-			result, err := h.gemini.URLScan(context.TODO(), initialPrompt, id)
-			if err != nil {
-
-				errorMessage := TextMessage{
-					ctx:    context.TODO(),
-					client: h.client,
-					evt:    evt,
-					text:   "error scanning url" + Footer,
+			var result *ai.URLScanResult
+			limited := !h.allowScan(evt)
+			if limited {
+				// Melebihi batas scan: verdict heuristik tidak memakai kuota Gemini dan tidak di-cache,
+				// agar link yang sama nanti tetap mendapat analisis penuh.
+				h.logger.Infof("Gemini scan for %s rate limited, using heuristics", url)
+				result = h.gemini.HeuristicScan(url, id)
+			} else if result, err = h.gemini.URLScan(context.TODO(), initialPrompt, id); err != nil {
+				// Gemini gagal (gangguan atau kuota habis): tetap beri verdict dari pemeriksaan berbasis aturan.
+				h.logger.Warnf("error scanning url %s, falling back to heuristics: %v", url, err)
+				result = h.gemini.HeuristicScan(url, id)
+			}
+			if len(url) <= 512 {
+				result.URL = url
+			}
+			if !limited {
+				if err := h.urlCache.Put(context.TODO(), url, result); err != nil {
+					h.logger.Warnf("error saving url cache for %s, err: %v", url, err)
 				}
-				ReplyToTextMesssage(errorMessage)
-
-				fmt.Printf("error scanning url %s: %v\n", url, err)
-				continue // Skip to the next URL
 			}
 			textMessage := TextMessage{
 				ctx:    context.TODO(),
@@ -35,6 +35,12 @@ func SendTextMessage(t TextMessage) (whatsmeow.SendResponse, error) {
 	return t.client.SendMessage(t.ctx, chatJID, msg)
 }
 
+// SendTextToJID mengirim pesan teks ke JID tertentu, misal DM owner, tanpa bergantung pada event masuk.
+func SendTextToJID(ctx context.Context, client *whatsmeow.Client, to types.JID, text string) (whatsmeow.SendResponse, error) {
+	msg := &waE2E.Message{Conversation: proto.String(text)}
+	return client.SendMessage(ctx, to, msg)
+}
+
 func ReplyToTextMesssage(t TextMessage) (whatsmeow.SendResponse, error) {
 	recipient := t.evt.Info.Chat
 	t.client.SendChatPresence(recipient, types.ChatPresenceComposing, types.ChatPresenceMediaText)
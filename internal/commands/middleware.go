@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// Middleware membungkus CommandFunc untuk menambahkan perilaku lintas perintah
+// (izin, logging, cooldown, dsb.) tanpa mengubah fungsi dispatch utama.
+// Command yang diterima sudah berisi definisi perintah (Name, PermissionLevel, ...)
+// beserta konteks pemanggilannya.
+type Middleware func(next CommandFunc) CommandFunc
+
+// Use mendaftarkan middleware global yang membungkus semua perintah.
+// Middleware yang didaftarkan lebih dulu berada di lapisan paling luar.
+func (h *Handler) Use(mw ...Middleware) {
+	h.middlewares = append(h.middlewares, mw...)
+}
+
+// chain merangkai middleware global, lalu middleware milik perintah, di sekitar Handler perintah.
+func (h *Handler) chain(cmd *Command) CommandFunc {
+	mws := slices.Concat(h.middlewares, cmd.Middlewares)
+	final := cmd.Handler
+	for i := len(mws) - 1; i >= 0; i-- {
+		final = mws[i](final)
+	}
+	return final
+}
+
+// permissionMiddleware menghentikan eksekusi secara diam-diam jika pengirim tidak punya izin.
+func (h *Handler) permissionMiddleware(next CommandFunc) CommandFunc {
+	return func(c Command) (whatsmeow.SendResponse, error) {
+		if !h.checkPermission(c.evt.Info.Sender.ToNonAD(), c.evt.Info.Chat, &c) {
+			h.logger.Debugf("Permission denied for command '%s' from %s", c.Name, c.evt.Info.Sender)
+			return whatsmeow.SendResponse{}, nil
+		}
+		return next(c)
+	}
+}
+
+// argsMiddleware memecah dan memparsing argumen sesuai skema perintah.
+// Jika input tidak valid, pengirim mendapat balasan format penggunaan dan Handler tidak dijalankan.
+func (h *Handler) argsMiddleware(next CommandFunc) CommandFunc {
+	return func(c Command) (whatsmeow.SendResponse, error) {
+		var args []string
+		var err error
+		if c.RawArgs {
+			args = strings.Fields(c.rawArgs)
+		} else {
+			args, err = splitArgs(c.rawArgs)
+		}
+		if err == nil {
+			c.args = args
+			c.parsed, err = c.parseArgs(args, c.evt)
+		}
+		if err != nil {
+			return h.replyUsageError(c, err)
+		}
+		return next(c)
+	}
+}
+
+// loggingMiddleware mencatat setiap eksekusi perintah beserta durasinya.
+func (h *Handler) loggingMiddleware(next CommandFunc) CommandFunc {
+	return func(c Command) (whatsmeow.SendResponse, error) {
+		h.logger.Infof("Executing command '%s' from %s with args: %v", c.Name, c.evt.Info.Sender, c.args)
+		start := time.Now()
+		resp, err := next(c)
+		h.logger.Debugf("Command '%s' finished in %s", c.Name, time.Since(start))
+		return resp, err
+	}
+}
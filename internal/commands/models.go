@@ -17,11 +17,68 @@ const (
 	Owner
 )
 
+// String mengembalikan nama level izin yang ditampilkan di menu bantuan.
+func (p PermissionLevel) String() string {
+	switch p {
+	case Everyone:
+		return "Semua orang"
+	case CertainChat:
+		return "Chat yang diizinkan"
+	case GroupAdmin:
+		return "Admin grup"
+	case SuperAdmin:
+		return "Pembuat grup"
+	case Owner:
+		return "Owner"
+	default:
+		return "Tidak diketahui"
+	}
+}
+
+// Category mengelompokkan perintah di menu bantuan.
+type Category string
+
+const (
+	CategoryGeneral    Category = "Umum"
+	CategoryManagement Category = "Manajemen"
+	CategoryDebug      Category = "Debug"
+)
+
+// categoryOrder menentukan urutan kategori saat menu bantuan dirender.
+var categoryOrder = []Category{CategoryGeneral, CategoryManagement, CategoryDebug}
+
 type Command struct {
-	ctx             context.Context
-	client          *whatsmeow.Client
-	evt             *events.Message
-	args            []string
+	ctx     context.Context
+	client  *whatsmeow.Client
+	evt     *events.Message
+	args    []string
+	rawArgs string
+	parsed  ParsedArgs
+
+	// Name adalah nama utama perintah tanpa prefix, misal "ping".
+	Name string
+	// Aliases adalah nama lain yang juga memicu perintah ini.
+	Aliases []string
+	// Summary adalah deskripsi satu baris untuk menu bantuan.
+	Summary string
+	// Usage adalah format argumen tanpa nama perintah, misal "<user_id>".
+	// Jika kosong, dibuat otomatis dari Args dan Flags.
+	Usage string
+	// Examples berisi contoh argumen, masing-masing dirender sebagai ".<name> <contoh>".
+	Examples []string
+	Category Category
+
+	// Args dan Flags adalah skema argumen yang diparsing sebelum Handler dijalankan.
+	Args  []ArgSpec
+	Flags []FlagSpec
+	// RawArgs mematikan pemrosesan kutip dan backslash; token hanya dipisah spasi.
+	// Berguna untuk perintah yang menerima teks bebas.
+	RawArgs bool
+
 	PermissionLevel PermissionLevel
 	Handler         CommandFunc
+	// Cooldown menimpa batas pemakaian default; nil berarti memakai default dari config.
+	Cooldown *Cooldown
+	// Middlewares khusus perintah ini, dijalankan setelah middleware global.
+	Middlewares []Middleware
 }
@@ -0,0 +1,148 @@
+package commands
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// register menambahkan perintah beserta aliasnya ke registry.
+// Nama atau alias yang bentrok adalah kesalahan programmer, jadi langsung panic saat startup.
+func (h *Handler) register(cmd *Command) {
+	name := strings.ToLower(cmd.Name)
+	if name == "" {
+		panic("commands: perintah tanpa nama")
+	}
+	if cmd.Category == "" {
+		cmd.Category = CategoryGeneral
+	}
+	for i, spec := range cmd.Args {
+		if spec.Variadic && i != len(cmd.Args)-1 {
+			panic(fmt.Sprintf("commands: argumen variadic %q pada %q harus di posisi terakhir", spec.Name, name))
+		}
+	}
+
+	if _, exists := h.lookup(name); exists {
+		panic(fmt.Sprintf("commands: perintah %q sudah terdaftar", name))
+	}
+	h.registry[name] = cmd
+
+	for _, alias := range cmd.Aliases {
+		alias = strings.ToLower(alias)
+		if _, exists := h.lookup(alias); exists {
+			panic(fmt.Sprintf("commands: alias %q untuk %q sudah terdaftar", alias, name))
+		}
+		h.aliases[alias] = name
+	}
+}
+
+// lookup mencari perintah berdasarkan nama utama atau alias.
+func (h *Handler) lookup(name string) (*Command, bool) {
+	name = strings.ToLower(name)
+	if cmd, ok := h.registry[name]; ok {
+		return cmd, true
+	}
+	if target, ok := h.aliases[name]; ok {
+		cmd, ok := h.registry[target]
+		return cmd, ok
+	}
+	return nil, false
+}
+
+// visibleCommands mengembalikan perintah yang boleh dijalankan oleh pengirim di chat tersebut,
+// diurutkan berdasarkan nama.
+func (h *Handler) visibleCommands(senderJID, chatJID types.JID) []*Command {
+	var cmds []*Command
+	for _, cmd := range h.registry {
+		if h.checkPermission(senderJID, chatJID, cmd) {
+			cmds = append(cmds, cmd)
+		}
+	}
+	slices.SortFunc(cmds, func(a, b *Command) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return cmds
+}
+
+// renderHelpMenu merakit daftar perintah dalam format WhatsApp, dikelompokkan per kategori.
+func (h *Handler) renderHelpMenu(cmds []*Command) string {
+	byCategory := make(map[Category][]*Command)
+	for _, cmd := range cmds {
+		byCategory[cmd.Category] = append(byCategory[cmd.Category], cmd)
+	}
+
+	// Kategori yang tidak ada di categoryOrder tetap ditampilkan di akhir.
+	categories := slices.Clone(categoryOrder)
+	for category := range byCategory {
+		if !slices.Contains(categories, category) {
+			categories = append(categories, category)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📖 *DAFTAR PERINTAH*\n")
+	sb.WriteString("━━━━━━━━━━━━━━━━━━━━\n")
+
+	for _, category := range categories {
+		list := byCategory[category]
+		if len(list) == 0 {
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\n*%s*\n", category))
+		for _, cmd := range list {
+			sb.WriteString(fmt.Sprintf("• `%s%s`", h.prefix, cmd.Name))
+			if cmd.Summary != "" {
+				sb.WriteString(" — " + cmd.Summary)
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("\nKetik `%shelp <perintah>` untuk detail.", h.prefix))
+	return sb.String()
+}
+
+// renderCommandHelp merakit detail satu perintah: ringkasan, penggunaan, alias, contoh dan izin.
+func (h *Handler) renderCommandHelp(cmd *Command) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📖 *%s%s*\n", h.prefix, cmd.Name))
+	if cmd.Summary != "" {
+		sb.WriteString(fmt.Sprintf("_%s_\n", cmd.Summary))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n*Penggunaan:* `%s`\n", h.usageLine(cmd)))
+
+	if len(cmd.Aliases) > 0 {
+		aliases := make([]string, len(cmd.Aliases))
+		for i, alias := range cmd.Aliases {
+			aliases[i] = h.prefix + alias
+		}
+		sb.WriteString(fmt.Sprintf("*Alias:* %s\n", strings.Join(aliases, ", ")))
+	}
+
+	if len(cmd.Examples) > 0 {
+		sb.WriteString("*Contoh:*\n")
+		for _, example := range cmd.Examples {
+			line := strings.TrimSpace(fmt.Sprintf("%s%s %s", h.prefix, cmd.Name, example))
+			sb.WriteString(fmt.Sprintf("• `%s`\n", line))
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("*Kategori:* %s\n", cmd.Category))
+	sb.WriteString(fmt.Sprintf("*Izin:* %s", cmd.PermissionLevel))
+	return sb.String()
+}
+
+// usageLine mengembalikan baris penggunaan lengkap dengan prefix, misal ".help [perintah]".
+func (h *Handler) usageLine(cmd *Command) string {
+	usage := cmd.Usage
+	if usage == "" {
+		usage = cmd.generatedUsage()
+	}
+	if usage == "" {
+		return h.prefix + cmd.Name
+	}
+	return fmt.Sprintf("%s%s %s", h.prefix, cmd.Name, usage)
+}
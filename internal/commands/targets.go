@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"go.mau.fi/whatsmeow/types"
+)
+
+// commandTargets mengumpulkan pengguna yang dituju sebuah perintah dari argumen
+// (@mention, nomor telepon, JID) dan dari pesan yang di-reply.
+func (h *Handler) commandTargets(c Command, argName string) []types.JID {
+	targets := c.parsed.JIDs(argName)
+
+	ctxInfo := c.evt.Message.GetExtendedTextMessage().GetContextInfo()
+	if ctxInfo.GetStanzaID() != "" && ctxInfo.GetParticipant() != "" {
+		if quoted, err := types.ParseJID(ctxInfo.GetParticipant()); err == nil {
+			targets = append(targets, quoted.ToNonAD())
+		}
+	}
+
+	seen := make(map[types.JID]bool)
+	return slices.DeleteFunc(targets, func(jid types.JID) bool {
+		jid = jid.ToNonAD()
+		if seen[jid] {
+			return true
+		}
+		seen[jid] = true
+		return false
+	})
+}
+
+// displayName mengembalikan nama kontak atau nama grup untuk ditampilkan, dengan JID sebagai cadangan.
+func (h *Handler) displayName(ctx context.Context, jid types.JID) string {
+	if jid.Server == types.GroupServer {
+		info, err := h.client.GetGroupInfo(jid)
+		if err != nil || info.Name == "" {
+			return jid.String()
+		}
+		return fmt.Sprintf("%s (%s)", info.Name, jid)
+	}
+
+	id := jid.String()
+	if jid.Server == types.DefaultUserServer {
+		id = "+" + jid.User
+	}
+
+	contact, err := h.client.Store.Contacts.GetContact(ctx, jid)
+	if err != nil || !contact.Found {
+		return id
+	}
+	for _, name := range []string{contact.FullName, contact.PushName, contact.BusinessName, contact.FirstName} {
+		if name != "" {
+			return fmt.Sprintf("%s (%s)", name, id)
+		}
+	}
+	return id
+}
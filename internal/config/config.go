@@ -6,12 +6,78 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// DefaultAFKMessage adalah template balasan AFK jika AFK_MESSAGE tidak diisi.
+const DefaultAFKMessage = "Hai! 👋 Terima kasih atas pesannya. Saat ini saya sedang dalam mode istirahat sampai {until} dan semua notifikasi sedang nonaktif. Pesan Anda sudah diterima dengan baik dan akan saya balas nanti ya. Terima kasih!"
+
+// DefaultAFKCalendarMessage adalah template balasan saat ada event kalender jika AFK_CALENDAR_MESSAGE tidak diisi.
+const DefaultAFKCalendarMessage = "Hai! 👋 Saat ini saya sedang {event} sampai {until}. Pesan Anda sudah diterima dan akan saya balas setelahnya. Terima kasih!"
+
+// DefaultURLCacheTTL adalah masa berlaku cache hasil scan URL per kategori jika URL_CACHE_TTL tidak diisi.
+// Verdict SAFE dibuat pendek karena situs bisa diretas atau berganti pemilik; PHISHING dan MALWARE jarang berubah.
+const DefaultURLCacheTTL = "SAFE=6h,ADVERTISEMENT=1d,SUSPICIOUS=6h,PHISHING=30d,MALWARE=30d,*=1h"
+
+// DefaultAFKManualMessage adalah template balasan AFK manual jika AFK_MANUAL_MESSAGE tidak diisi.
+const DefaultAFKManualMessage = "Hai! 👋 Saat ini saya sedang AFK sejak {since}: _{reason}_. Pesan Anda sudah diterima dan akan saya balas setelah kembali. Terima kasih!"
+
+// DefaultProtectedBrands adalah domain resmi yang tiruannya dideteksi saat scan URL jika PROTECTED_BRANDS tidak diisi.
+// Isinya bank, e-wallet dan marketplace yang paling sering ditiru di grup Indonesia. Domain resmi lain milik
+// merek yang sama ditulis setelah "|" agar tidak dianggap tiruan.
+const DefaultProtectedBrands = "bca.co.id,klikbca.com,bri.co.id,bankmandiri.co.id,livin.id,bni.co.id,btn.co.id," +
+	"bankbsi.co.id,cimbniaga.co.id,danamon.co.id,permatabank.com,ocbc.id,jenius.com,seabank.co.id,jago.com," +
+	"dana.id,ovo.id,gopay.co.id,gojek.com|gojekapi.com,linkaja.id,shopeepay.co.id,shopee.co.id|shopee.com|shopeemobile.com," +
+	"tokopedia.com|tokopedia.net,bukalapak.com,lazada.co.id|lazada.com,blibli.com," +
+	"tiktok.com|tiktokcdn.com|tiktokv.com,whatsapp.com|whatsapp.net|wa.me," +
+	"facebook.com|facebook.net|fb.com|fbcdn.net,instagram.com|cdninstagram.com,paypal.com|paypal.me|paypalobjects.com"
+
 type Config struct {
 	OwnerID      string
 	GeminiAPIKey string
 	GSBAPIKey    string
 	PostgressURI string
 	OwnerLID     string
+
+	// CommandUserRate dan CommandChatRate adalah batas default pemakaian perintah
+	// per pengirim dan per chat dengan format "<jumlah>/<durasi>", misal "3/10s".
+	CommandUserRate string
+	CommandChatRate string
+	// CommandCooldowns menimpa batas per perintah dengan format "nama=<per-user>[:<per-chat>],...",
+	// misal "scan=1/30s,afklist=2/1m:5/1m". "scan" adalah pemindaian link otomatis lewat Gemini.
+	CommandCooldowns string
+
+	// StorageBackend menentukan tempat data bot disimpan: "postgres" (default) atau "json".
+	StorageBackend string
+	// DataDir adalah direktori file data untuk backend "json".
+	DataDir string
+
+	// Pengaturan AFK awal. Setelah diubah lewat perintah .afk, nilai tersimpan yang dipakai.
+	AFKTimezone string
+	// AFKSchedule berformat "[hari] HH:MM-HH:MM; ...", misal "mon-fri 22:00-07:00; sat,sun 00:00-09:00".
+	AFKSchedule string
+	// AFKMessage adalah template balasan AFK dengan placeholder {name}, {sender} dan {until}.
+	AFKMessage string
+	// AFKManualMessage adalah template balasan saat AFK manual, dengan placeholder tambahan {reason} dan {since}.
+	AFKManualMessage string
+	// AFKCalendars adalah daftar file .ics (dipisah koma) berisi libur, cuti atau rapat yang membuat AFK otomatis.
+	AFKCalendars string
+	// AFKCalendarMessage adalah template balasan saat event kalender berlangsung, dengan placeholder tambahan {event}.
+	AFKCalendarMessage string
+	// AFKRenotifyInterval adalah jeda sebelum chat yang sama dibalas AFK lagi, misal "2h". "0" berarti sekali per periode AFK.
+	AFKRenotifyInterval string
+	AFKName             string
+	// URLCacheTTL berformat "KATEGORI=durasi,...", "*" untuk kategori lain. Durasi 0 berarti tidak di-cache.
+	URLCacheTTL string
+	// ThreatFeeds adalah daftar feed phishing/malware berformat "KATEGORI=sumber,...", sumber berupa URL http(s) atau path file.
+	ThreatFeeds string
+	// ThreatFeedRefresh adalah interval pemuatan ulang feed, misal "6h". "0" berarti hanya dimuat saat start.
+	ThreatFeedRefresh string
+	// ProtectedBrands adalah daftar domain resmi (dipisah koma) yang tiruannya dideteksi oleh analisis leksikal.
+	// Domain resmi lain milik merek yang sama ditulis setelah "|", misal "whatsapp.com|whatsapp.net|wa.me".
+	ProtectedBrands string
+
+	// Jalur darurat untuk pesan dari kontak VIP selama AFK. AFKVIPForwardTo adalah nomor
+	// atau JID tujuan salinan pesan, AFKVIPWebhook adalah URL yang menerima POST JSON.
+	AFKVIPForwardTo string
+	AFKVIPWebhook   string
 }
 
 // TODO:IMPROVE THIS FUNCTION
@@ -26,6 +92,38 @@ func LoadConfig() (Config, error) {
 		GSBAPIKey:    os.Getenv("GOOGLE_SAFE_BROWSING_API_KEY"),
 		PostgressURI: os.Getenv("POSTGRES_URI"),
 		OwnerLID:     os.Getenv("OWNER_LID"),
+
+		CommandUserRate:  getEnvDefault("COMMAND_USER_RATE", "3/10s"),
+		CommandChatRate:  getEnvDefault("COMMAND_CHAT_RATE", "10/30s"),
+		CommandCooldowns: os.Getenv("COMMAND_COOLDOWNS"),
+
+		StorageBackend: getEnvDefault("STORAGE_BACKEND", "postgres"),
+		DataDir:        getEnvDefault("DATA_DIR", "data"),
+
+		AFKTimezone: getEnvDefault("AFK_TIMEZONE", "Asia/Jakarta"),
+		AFKSchedule: getEnvDefault("AFK_SCHEDULE", "22:00-07:00"),
+		AFKMessage:  getEnvDefault("AFK_MESSAGE", DefaultAFKMessage),
+		AFKName:     os.Getenv("AFK_NAME"),
+
+		AFKManualMessage:    getEnvDefault("AFK_MANUAL_MESSAGE", DefaultAFKManualMessage),
+		AFKRenotifyInterval: getEnvDefault("AFK_RENOTIFY_INTERVAL", "2h"),
+		AFKCalendars:        os.Getenv("AFK_CALENDARS"),
+		AFKCalendarMessage:  getEnvDefault("AFK_CALENDAR_MESSAGE", DefaultAFKCalendarMessage),
+		AFKVIPForwardTo:     os.Getenv("AFK_VIP_FORWARD_TO"),
+		AFKVIPWebhook:       os.Getenv("AFK_VIP_WEBHOOK"),
+
+		URLCacheTTL:       getEnvDefault("URL_CACHE_TTL", DefaultURLCacheTTL),
+		ThreatFeeds:       os.Getenv("THREAT_FEEDS"),
+		ThreatFeedRefresh: getEnvDefault("THREAT_FEED_REFRESH", "6h"),
+		ProtectedBrands:   getEnvDefault("PROTECTED_BRANDS", DefaultProtectedBrands),
 	}, nil
 
 }
+
+// getEnvDefault mengembalikan nilai environment variable, atau fallback jika kosong.
+func getEnvDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,185 @@
+// Package domainlist menyimpan daftar domain yang dilewati (allow) atau langsung
+// dianggap berbahaya (block) sebelum URL dikirim ke pipeline AI.
+package domainlist
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Satr10/wa-userbot/internal/urlcanon"
+)
+
+// Nama daftar yang didukung.
+const (
+	Allow = "allow"
+	Block = "block"
+)
+
+// Rule adalah satu pola domain dalam sebuah daftar. Pattern bisa berupa:
+//   - domain persis, misal "google.com" (hanya cocok dengan host itu);
+//   - wildcard, misal "*.ourcorp.id" (cocok dengan ourcorp.id dan semua subdomainnya);
+//   - regex di antara garis miring, misal "/^login-.*\.xyz$/" (dicocokkan dengan host).
+type Rule struct {
+	List    string `json:"list"`
+	Pattern string `json:"pattern"`
+}
+
+// compiledRule adalah Rule yang sudah siap dicocokkan.
+type compiledRule struct {
+	Rule
+	host   string
+	suffix string
+	re     *regexp.Regexp
+}
+
+func (r compiledRule) matches(host string) bool {
+	switch {
+	case r.re != nil:
+		return r.re.MatchString(host)
+	case r.suffix != "":
+		return host == r.suffix || strings.HasSuffix(host, "."+r.suffix)
+	default:
+		return host == r.host
+	}
+}
+
+// compile memvalidasi dan menormalkan pola. Domain dan wildcard diubah ke bentuk kanonik
+// (huruf kecil, punycode) agar sama dengan host dari urlcanon.Canonicalize.
+func compile(rule Rule) (compiledRule, error) {
+	if rule.List != Allow && rule.List != Block {
+		return compiledRule{}, fmt.Errorf("daftar %q tidak dikenal", rule.List)
+	}
+	pattern := strings.TrimSpace(rule.Pattern)
+
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("regex %s tidak valid: %w", pattern, err)
+		}
+		return compiledRule{Rule: Rule{List: rule.List, Pattern: pattern}, re: re}, nil
+	}
+
+	wildcard := strings.HasPrefix(pattern, "*.")
+	host, err := urlcanon.CanonicalHost(strings.TrimPrefix(pattern, "*."))
+	if err != nil || strings.ContainsAny(host, "/*?#@ ") {
+		return compiledRule{}, fmt.Errorf("pola domain %q tidak valid", pattern)
+	}
+	if wildcard {
+		return compiledRule{Rule: Rule{List: rule.List, Pattern: "*." + host}, suffix: host}, nil
+	}
+	return compiledRule{Rule: Rule{List: rule.List, Pattern: host}, host: host}, nil
+}
+
+// Manager memegang daftar allow dan block dan menyimpannya lewat Store.
+type Manager struct {
+	mu    sync.RWMutex
+	rules []compiledRule
+	store Store
+}
+
+// NewManager memuat daftar domain dari store.
+func NewManager(ctx context.Context, store Store) (*Manager, error) {
+	rules, err := store.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gagal memuat daftar domain: %w", err)
+	}
+
+	m := &Manager{store: store}
+	for _, rule := range rules {
+		compiled, err := compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("aturan domain tersimpan tidak valid: %w", err)
+		}
+		m.rules = append(m.rules, compiled)
+	}
+	return m, nil
+}
+
+// Add menambahkan pola ke daftar dan menyimpannya. Pola yang dikembalikan adalah bentuk
+// normalnya, misal "*.Bücher.de" menjadi "*.xn--bcher-kva.de".
+func (m *Manager) Add(ctx context.Context, list, pattern string) (string, error) {
+	compiled, err := compile(Rule{List: list, Pattern: pattern})
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if slices.ContainsFunc(m.rules, func(r compiledRule) bool { return r.Rule == compiled.Rule }) {
+		return compiled.Pattern, nil
+	}
+	return compiled.Pattern, m.replace(ctx, append(slices.Clone(m.rules), compiled))
+}
+
+// Remove menghapus pola dari daftar dan menyimpannya. found bernilai false jika pola tidak ada.
+func (m *Manager) Remove(ctx context.Context, list, pattern string) (found bool, err error) {
+	// Normalkan dulu agar "Google.com" menghapus "google.com".
+	if compiled, err := compile(Rule{List: list, Pattern: pattern}); err == nil {
+		pattern = compiled.Pattern
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rules := slices.DeleteFunc(slices.Clone(m.rules), func(r compiledRule) bool {
+		return r.List == list && r.Pattern == pattern
+	})
+	if len(rules) == len(m.rules) {
+		return false, nil
+	}
+	return true, m.replace(ctx, rules)
+}
+
+// Rules mengembalikan semua pola dalam daftar, sesuai urutan penambahan.
+func (m *Manager) Rules(list string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var patterns []string
+	for _, r := range m.rules {
+		if r.List == list {
+			patterns = append(patterns, r.Pattern)
+		}
+	}
+	return patterns
+}
+
+// Match mencari aturan yang cocok dengan host URL kanonik. Daftar block didahulukan
+// sehingga domain yang ada di kedua daftar tetap dianggap berbahaya.
+// ok bernilai false jika URL tidak punya host atau tidak ada aturan yang cocok.
+func (m *Manager) Match(canonicalURL string) (rule Rule, ok bool) {
+	u, err := url.Parse(canonicalURL)
+	if err != nil || u.Hostname() == "" {
+		return Rule{}, false
+	}
+	host := u.Hostname()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, list := range []string{Block, Allow} {
+		for _, r := range m.rules {
+			if r.List == list && r.matches(host) {
+				return r.Rule, true
+			}
+		}
+	}
+	return Rule{}, false
+}
+
+// replace menyimpan daftar aturan baru lalu memakainya. Jika penyimpanan gagal, daftar lama
+// tetap berlaku agar aturan di memori tidak berbeda dengan yang tersimpan. Pemanggil harus
+// memegang m.mu.
+func (m *Manager) replace(ctx context.Context, compiled []compiledRule) error {
+	rules := make([]Rule, len(compiled))
+	for i, r := range compiled {
+		rules[i] = r.Rule
+	}
+	if err := m.store.Save(ctx, rules); err != nil {
+		return err
+	}
+	m.rules = compiled
+	return nil
+}
@@ -0,0 +1,123 @@
+package domainlist
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/Satr10/wa-userbot/internal/storage"
+	"github.com/bytedance/sonic"
+)
+
+// Store adalah tempat penyimpanan daftar domain.
+// Save selalu menerima seluruh aturan sehingga implementasi bebas menulis ulang seluruhnya.
+type Store interface {
+	Load(ctx context.Context) ([]Rule, error)
+	Save(ctx context.Context, rules []Rule) error
+}
+
+// NewStore memilih implementasi Store sesuai jenis backend.
+func NewStore(ctx context.Context, backend *storage.Backend) (Store, error) {
+	switch backend.Kind {
+	case storage.KindPostgres:
+		return NewPostgresStore(ctx, backend.DB)
+	case storage.KindJSON:
+		return NewJSONStore(backend.Path("domain_lists.json")), nil
+	default:
+		return nil, fmt.Errorf("storage backend %q tidak didukung untuk daftar domain", backend.Kind)
+	}
+}
+
+// JSONStore menyimpan daftar domain sebagai satu file JSON.
+type JSONStore struct {
+	filePath string
+}
+
+// NewJSONStore membuat store berbasis file JSON di path tersebut.
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{filePath: path}
+}
+
+func (s *JSONStore) Load(ctx context.Context) ([]Rule, error) {
+	file, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var rules []Rule
+	if err := sonic.Unmarshal(file, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (s *JSONStore) Save(ctx context.Context, rules []Rule) error {
+	raw, err := sonic.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFileAtomic(s.filePath, raw)
+}
+
+// domainListMigrations adalah skema tabel daftar domain. Jangan ubah entri lama, tambahkan versi baru di akhir.
+var domainListMigrations = []string{
+	`CREATE TABLE domain_rules (
+		id      SERIAL PRIMARY KEY,
+		list    TEXT NOT NULL,
+		pattern TEXT NOT NULL,
+		UNIQUE (list, pattern)
+	);`,
+}
+
+// PostgresStore menyimpan daftar domain di tabel domain_rules, satu baris per aturan.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore membuat store Postgres dan menjalankan migrasi skema yang belum diterapkan.
+func NewPostgresStore(ctx context.Context, db *sql.DB) (*PostgresStore, error) {
+	if err := storage.Migrate(ctx, db, "domainlist", domainListMigrations); err != nil {
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+func (s *PostgresStore) Load(ctx context.Context) ([]Rule, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT list, pattern FROM domain_rules ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca daftar domain: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		var rule Rule
+		if err := rows.Scan(&rule.List, &rule.Pattern); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// Save menulis ulang seluruh tabel daftar domain dalam satu transaksi.
+func (s *PostgresStore) Save(ctx context.Context, rules []Rule) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM domain_rules`); err != nil {
+		return fmt.Errorf("gagal mengosongkan domain_rules: %w", err)
+	}
+	for _, rule := range rules {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO domain_rules (list, pattern) VALUES ($1, $2)`, rule.List, rule.Pattern); err != nil {
+			return fmt.Errorf("gagal menyimpan aturan %s %s: %w", rule.List, rule.Pattern, err)
+		}
+	}
+	return tx.Commit()
+}
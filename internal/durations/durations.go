@@ -0,0 +1,35 @@
+// Package durations mengurai durasi yang ditulis pengguna di perintah dan konfigurasi,
+// sehingga semua tempat menerima akhiran yang sama.
+package durations
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// units adalah akhiran tambahan di luar time.ParseDuration, beserta panjang satuannya.
+var units = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// Parse menerima format time.ParseDuration ditambah akhiran "d" untuk hari dan "w" untuk
+// minggu, misal "90m", "3d" atau "2w". Durasi negatif ditolak.
+func Parse(s string) (time.Duration, error) {
+	for suffix, unit := range units {
+		if value, ok := strings.CutSuffix(s, suffix); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("durasi tidak valid: %s", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("durasi tidak valid: %s", s)
+	}
+	return d, nil
+}
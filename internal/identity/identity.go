@@ -0,0 +1,113 @@
+package identity
+
+import (
+	"context"
+	"slices"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+	waLog "go.mau.fi/whatsmeow/util/log"
+)
+
+// Resolver menyatukan dua identitas WhatsApp milik pengguna yang sama:
+// nomor telepon (PN, @s.whatsapp.net) dan LID (@lid) yang dipakai di grup.
+// Pemetaan diambil dari LID store milik whatsmeow.
+type Resolver struct {
+	client *whatsmeow.Client
+	logger waLog.Logger
+}
+
+// NewResolver membuat resolver yang memakai store dari client tersebut.
+func NewResolver(client *whatsmeow.Client, logger waLog.Logger) *Resolver {
+	return &Resolver{client: client, logger: logger}
+}
+
+// Normalize membuang suffix device/agent sehingga JID bisa dibandingkan langsung.
+func Normalize(jid types.JID) types.JID {
+	return jid.ToNonAD()
+}
+
+// Linked mengembalikan pasangan identitas dari jid: LID untuk nomor telepon, atau
+// nomor telepon untuk LID. JID kosong dikembalikan jika pasangannya belum diketahui.
+func (r *Resolver) Linked(ctx context.Context, jid types.JID) types.JID {
+	jid = Normalize(jid)
+
+	var linked types.JID
+	var err error
+	switch jid.Server {
+	case types.DefaultUserServer:
+		linked, err = r.client.Store.LIDs.GetLIDForPN(ctx, jid)
+	case types.HiddenUserServer:
+		linked, err = r.client.Store.LIDs.GetPNForLID(ctx, jid)
+	default:
+		return types.JID{}
+	}
+	if err != nil {
+		r.logger.Warnf("error resolving linked identity for %s, err: %v", jid, err)
+		return types.JID{}
+	}
+	if linked.IsEmpty() {
+		return types.JID{}
+	}
+	return Normalize(linked)
+}
+
+// Aliases mengembalikan jid yang sudah dinormalisasi beserta pasangannya jika diketahui.
+func (r *Resolver) Aliases(ctx context.Context, jid types.JID) []types.JID {
+	jid = Normalize(jid)
+	aliases := []types.JID{jid}
+	if linked := r.Linked(ctx, jid); !linked.IsEmpty() {
+		aliases = append(aliases, linked)
+	}
+	return aliases
+}
+
+// Keys sama dengan Aliases, tetapi dalam bentuk string untuk mencocokkan data izin.
+func (r *Resolver) Keys(ctx context.Context, jid types.JID) []string {
+	aliases := r.Aliases(ctx, jid)
+	keys := make([]string, len(aliases))
+	for i, alias := range aliases {
+		keys[i] = alias.String()
+	}
+	return keys
+}
+
+// Canonical mengembalikan bentuk yang disimpan di data izin: nomor telepon jika
+// diketahui, karena bentuk itu yang bisa dibaca dan diketik manual oleh owner.
+func (r *Resolver) Canonical(ctx context.Context, jid types.JID) types.JID {
+	jid = Normalize(jid)
+	if jid.Server == types.HiddenUserServer {
+		if pn := r.Linked(ctx, jid); !pn.IsEmpty() {
+			return pn
+		}
+	}
+	return jid
+}
+
+// Same melaporkan apakah a dan b adalah pengguna yang sama, dengan memperhitungkan PN dan LID.
+func (r *Resolver) Same(ctx context.Context, a, b types.JID) bool {
+	if a.IsEmpty() || b.IsEmpty() {
+		return false
+	}
+	a, b = Normalize(a), Normalize(b)
+	if a == b {
+		return true
+	}
+	return slices.Contains(r.Aliases(ctx, a), b)
+}
+
+// MatchesAny melaporkan apakah jid sama dengan salah satu kandidat.
+func (r *Resolver) MatchesAny(ctx context.Context, jid types.JID, candidates ...types.JID) bool {
+	aliases := r.Aliases(ctx, jid)
+	for _, candidate := range candidates {
+		if !candidate.IsEmpty() && slices.Contains(aliases, Normalize(candidate)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSelf melaporkan apakah jid adalah akun yang sedang login, baik lewat PN maupun LID.
+func (r *Resolver) IsSelf(ctx context.Context, jid types.JID) bool {
+	return r.MatchesAny(ctx, jid, r.client.Store.GetJID(), r.client.Store.GetLID())
+}
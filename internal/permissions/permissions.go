@@ -1,56 +1,151 @@
 package permissions
 
 import (
-	"os"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
 	"sync"
+)
 
-	"github.com/bytedance/sonic"
+// Role bawaan. Nama role lain tetap boleh dipakai, misal untuk grant per perintah.
+const (
+	RoleModerator = "moderator"
+	RoleTrusted   = "trusted"
+	RoleBanned    = "banned"
 )
 
-// Manager menampung dan mengelola data izin dari file JSON.
-type Manager struct {
+// Daftar AFK. Satu pengguna atau grup hanya bisa berada di salah satu daftar.
+const (
+	// AFKIgnore: tidak pernah dibalas pesan AFK (grup yang di-mute, bot, dsb.).
+	AFKIgnore = "ignore"
+	// AFKVIP: pesan selama AFK diteruskan lewat jalur notifikasi darurat.
+	AFKVIP = "vip"
+)
+
+// Data adalah seluruh data izin yang dimuat dan disimpan oleh Store.
+type Data struct {
 	AllowedGroups map[string]bool `json:"allowedGroups"`
 	AllowedUsers  map[string]bool `json:"allowedUsers"`
+	// Roles berisi role global per pengguna.
+	Roles map[string][]string `json:"roles"`
+	// GroupRoles berisi role per grup: groupID -> userID -> role.
+	GroupRoles map[string]map[string][]string `json:"groupRoles"`
+	// Grants berisi izin khusus per perintah: command -> subject -> allow.
+	// Subject berbentuk "user:<jid>" atau "role:<nama>", lihat UserSubject dan RoleSubject.
+	Grants map[string]map[string]bool `json:"grants"`
+	// AFKLists berisi pengguna atau grup yang diperlakukan khusus saat AFK: id -> AFKIgnore/AFKVIP.
+	AFKLists map[string]string `json:"afkLists"`
+}
 
-	mu       sync.RWMutex
-	filePath string
+// newData membuat Data kosong dengan semua map sudah terinisialisasi.
+func newData() *Data {
+	d := &Data{}
+	d.ensureMaps()
+	return d
 }
 
-// NewManager membuat instance baru dari permission manager.
-func NewManager(path string) (*Manager, error) {
-	m := &Manager{
-		filePath:      path,
-		AllowedGroups: make(map[string]bool),
-		AllowedUsers:  make(map[string]bool),
+// ensureMaps memastikan map tidak nil setelah memuat data lama yang belum punya field baru.
+func (d *Data) ensureMaps() {
+	if d.AllowedGroups == nil {
+		d.AllowedGroups = make(map[string]bool)
+	}
+	if d.AllowedUsers == nil {
+		d.AllowedUsers = make(map[string]bool)
 	}
+	if d.Roles == nil {
+		d.Roles = make(map[string][]string)
+	}
+	if d.GroupRoles == nil {
+		d.GroupRoles = make(map[string]map[string][]string)
+	}
+	if d.Grants == nil {
+		d.Grants = make(map[string]map[string]bool)
+	}
+	if d.AFKLists == nil {
+		d.AFKLists = make(map[string]string)
+	}
+}
 
-	file, err := os.ReadFile(path)
-	// Jika file tidak ada, tidak apa-apa. File akan dibuat saat pertama kali menyimpan.
-	if err != nil {
-		if os.IsNotExist(err) {
-			return m, nil
+// clone membuat salinan dalam (deep copy) dari Data.
+func (d *Data) clone() Data {
+	c := Data{
+		AllowedGroups: maps.Clone(d.AllowedGroups),
+		AllowedUsers:  maps.Clone(d.AllowedUsers),
+		Roles:         make(map[string][]string, len(d.Roles)),
+		GroupRoles:    make(map[string]map[string][]string, len(d.GroupRoles)),
+		Grants:        make(map[string]map[string]bool, len(d.Grants)),
+		AFKLists:      maps.Clone(d.AFKLists),
+	}
+	for user, roles := range d.Roles {
+		c.Roles[user] = slices.Clone(roles)
+	}
+	for group, users := range d.GroupRoles {
+		c.GroupRoles[group] = make(map[string][]string, len(users))
+		for user, roles := range users {
+			c.GroupRoles[group][user] = slices.Clone(roles)
 		}
-		return nil, err
 	}
+	for command, grants := range d.Grants {
+		c.Grants[command] = maps.Clone(grants)
+	}
+	return c
+}
+
+// Manager menampung dan mengelola data izin yang disimpan lewat Store.
+type Manager struct {
+	Data
 
-	// Jika file ada, muat datanya.
-	if err := sonic.Unmarshal(file, m); err != nil {
-		return nil, err
+	mu    sync.RWMutex
+	store Store
+	// saveMu menjaga urutan penyimpanan agar salinan lama tidak menimpa yang lebih baru.
+	saveMu sync.Mutex
+}
+
+// NewManager membuat instance baru dari permission manager dan memuat datanya dari store.
+func NewManager(ctx context.Context, store Store) (*Manager, error) {
+	data, err := store.Load(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gagal memuat data izin: %w", err)
 	}
+	data.ensureMaps()
 
-	return m, nil
+	return &Manager{Data: *data, store: store}, nil
 }
 
-// Save menyimpan data izin saat ini ke file JSON.
+// Save menyimpan data izin saat ini ke store.
 func (m *Manager) Save() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
 
-	data, err := sonic.MarshalIndent(m, "", "  ")
-	if err != nil {
+	m.mu.RLock()
+	data := m.Data.clone()
+	m.mu.RUnlock()
+
+	return m.store.Save(context.Background(), &data)
+}
+
+// update menerapkan perubahan ke salinan data, menyimpannya, lalu baru memakainya. Jika
+// penyimpanan gagal, data di memori tidak berubah sehingga tetap sama dengan yang tersimpan.
+// saveMu ditahan sepanjang proses agar perubahan lain tidak hilang tertimpa salinan ini.
+func (m *Manager) update(fn func(*Data)) error {
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
+
+	m.mu.RLock()
+	data := m.Data.clone()
+	m.mu.RUnlock()
+
+	data.ensureMaps()
+	fn(&data)
+	if err := m.store.Save(context.Background(), &data); err != nil {
 		return err
 	}
-	return os.WriteFile(m.filePath, data, 0644)
+
+	m.mu.Lock()
+	m.Data = data
+	m.mu.Unlock()
+	return nil
 }
 
 // IsGroupAllowed memeriksa apakah ID grup ada di dalam daftar izin.
@@ -69,32 +164,182 @@ func (m *Manager) IsUserAllowed(userID string) bool {
 
 // AddAllowedUser menambahkan pengguna ke daftar izin dan menyimpannya.
 func (m *Manager) AddAllowedUser(userID string) error {
-	m.mu.Lock()
-	m.AllowedUsers[userID] = true
-	m.mu.Unlock()
-	return m.Save()
+	return m.update(func(d *Data) {
+		d.AllowedUsers[userID] = true
+	})
 }
 
 // RemoveAllowedUser menghapus pengguna dari daftar izin dan menyimpannya.
 func (m *Manager) RemoveAllowedUser(userID string) error {
-	m.mu.Lock()
-	delete(m.AllowedUsers, userID)
-	m.mu.Unlock()
-	return m.Save()
+	return m.update(func(d *Data) {
+		delete(d.AllowedUsers, userID)
+	})
 }
 
 // AddAllowedGroup menambahkan grup ke daftar izin dan menyimpannya.
 func (m *Manager) AddAllowedGroup(groupID string) error {
-	m.mu.Lock()
-	m.AllowedGroups[groupID] = true
-	m.mu.Unlock()
-	return m.Save()
+	return m.update(func(d *Data) {
+		d.AllowedGroups[groupID] = true
+	})
 }
 
 // RemoveAllowedGroup menghapus grup dari daftar izin dan menyimpannya.
 func (m *Manager) RemoveAllowedGroup(groupID string) error {
-	m.mu.Lock()
-	delete(m.AllowedGroups, groupID)
-	m.mu.Unlock()
-	return m.Save()
+	return m.update(func(d *Data) {
+		delete(d.AllowedGroups, groupID)
+	})
+}
+
+// UserSubject membuat subject grant untuk satu pengguna.
+func UserSubject(userID string) string {
+	return "user:" + userID
+}
+
+// RoleSubject membuat subject grant untuk semua pemegang role.
+func RoleSubject(role string) string {
+	return "role:" + role
+}
+
+// UserRoles mengembalikan gabungan role global dan role di grup tersebut untuk semua
+// identitas pengguna (misal nomor telepon dan LID-nya). groupID boleh kosong untuk
+// hanya mengambil role global.
+func (m *Manager) UserRoles(groupID string, userIDs ...string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var roles []string
+	add := func(list []string) {
+		for _, role := range list {
+			if !slices.Contains(roles, role) {
+				roles = append(roles, role)
+			}
+		}
+	}
+	for _, userID := range userIDs {
+		add(m.Roles[userID])
+		if groupID != "" {
+			add(m.GroupRoles[groupID][userID])
+		}
+	}
+	return roles
+}
+
+// HasRole memeriksa apakah pengguna memegang role, baik global maupun di grup tersebut.
+func (m *Manager) HasRole(userID, groupID, role string) bool {
+	return slices.Contains(m.UserRoles(groupID, userID), role)
+}
+
+// AddRole memberikan role ke pengguna dan menyimpannya. groupID kosong berarti role global.
+func (m *Manager) AddRole(userID, groupID, role string) error {
+	return m.update(func(d *Data) {
+		if groupID == "" {
+			if !slices.Contains(d.Roles[userID], role) {
+				d.Roles[userID] = append(d.Roles[userID], role)
+			}
+		} else {
+			if d.GroupRoles[groupID] == nil {
+				d.GroupRoles[groupID] = make(map[string][]string)
+			}
+			if !slices.Contains(d.GroupRoles[groupID][userID], role) {
+				d.GroupRoles[groupID][userID] = append(d.GroupRoles[groupID][userID], role)
+			}
+		}
+	})
+}
+
+// RemoveRole mencabut role dari pengguna dan menyimpannya. groupID kosong berarti role global.
+func (m *Manager) RemoveRole(userID, groupID, role string) error {
+	return m.update(func(d *Data) {
+		if groupID == "" {
+			d.Roles[userID] = slices.DeleteFunc(d.Roles[userID], func(r string) bool { return r == role })
+			if len(d.Roles[userID]) == 0 {
+				delete(d.Roles, userID)
+			}
+		} else if users, ok := d.GroupRoles[groupID]; ok {
+			users[userID] = slices.DeleteFunc(users[userID], func(r string) bool { return r == role })
+			if len(users[userID]) == 0 {
+				delete(users, userID)
+			}
+			if len(users) == 0 {
+				delete(d.GroupRoles, groupID)
+			}
+		}
+	})
+}
+
+// SetGrant mengizinkan (allow=true) atau melarang (allow=false) subject menjalankan perintah.
+func (m *Manager) SetGrant(command, subject string, allow bool) error {
+	return m.update(func(d *Data) {
+		if d.Grants[command] == nil {
+			d.Grants[command] = make(map[string]bool)
+		}
+		d.Grants[command][subject] = allow
+	})
+}
+
+// RemoveGrant menghapus grant subject untuk perintah dan menyimpannya.
+func (m *Manager) RemoveGrant(command, subject string) error {
+	return m.update(func(d *Data) {
+		delete(d.Grants[command], subject)
+		if len(d.Grants[command]) == 0 {
+			delete(d.Grants, command)
+		}
+	})
+}
+
+// CheckGrant mencari grant untuk perintah di antara subject yang diberikan.
+// found bernilai false jika tidak ada grant yang cocok. Jika ada yang cocok, deny selalu menang atas allow.
+func (m *Manager) CheckGrant(command string, subjects ...string) (allow bool, found bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	grants := m.Grants[command]
+	for _, subject := range subjects {
+		granted, ok := grants[subject]
+		if !ok {
+			continue
+		}
+		if !granted {
+			return false, true
+		}
+		found = true
+	}
+	return found, found
+}
+
+// SetAFKList memasukkan pengguna atau grup ke daftar AFK (AFKIgnore atau AFKVIP) dan menyimpannya.
+// Entri lama untuk id yang sama diganti.
+func (m *Manager) SetAFKList(id, list string) error {
+	if list != AFKIgnore && list != AFKVIP {
+		return fmt.Errorf("daftar AFK %q tidak dikenal", list)
+	}
+	return m.update(func(d *Data) {
+		d.AFKLists[id] = list
+	})
+}
+
+// RemoveAFKList mengeluarkan pengguna atau grup dari daftar AFK dan menyimpannya.
+func (m *Manager) RemoveAFKList(id string) error {
+	return m.update(func(d *Data) {
+		delete(d.AFKLists, id)
+	})
+}
+
+// InAFKList memeriksa apakah salah satu id berada di daftar AFK tersebut.
+func (m *Manager) InAFKList(list string, ids ...string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, id := range ids {
+		if m.AFKLists[id] == list {
+			return true
+		}
+	}
+	return false
+}
+
+// Snapshot mengembalikan salinan seluruh data izin saat ini.
+func (m *Manager) Snapshot() Data {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.Data.clone()
 }
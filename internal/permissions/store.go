@@ -0,0 +1,27 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Satr10/wa-userbot/internal/storage"
+)
+
+// Store adalah tempat penyimpanan data izin.
+// Save selalu menerima data lengkap sehingga implementasi bebas menulis ulang seluruhnya.
+type Store interface {
+	Load(ctx context.Context) (*Data, error)
+	Save(ctx context.Context, data *Data) error
+}
+
+// NewStore memilih implementasi Store sesuai jenis backend.
+func NewStore(ctx context.Context, backend *storage.Backend) (Store, error) {
+	switch backend.Kind {
+	case storage.KindPostgres:
+		return NewPostgresStore(ctx, backend.DB)
+	case storage.KindJSON:
+		return NewJSONStore(backend.Path("permissions.json")), nil
+	default:
+		return nil, fmt.Errorf("storage backend %q tidak didukung untuk izin", backend.Kind)
+	}
+}
@@ -0,0 +1,55 @@
+package permissions
+
+import (
+	"context"
+	"os"
+	"sync"
+
+	"github.com/Satr10/wa-userbot/internal/storage"
+	"github.com/bytedance/sonic"
+)
+
+// JSONStore menyimpan data izin sebagai satu file JSON.
+type JSONStore struct {
+	mu       sync.Mutex
+	filePath string
+}
+
+// NewJSONStore membuat store berbasis file JSON di path tersebut.
+func NewJSONStore(path string) *JSONStore {
+	return &JSONStore{filePath: path}
+}
+
+// Load membaca file JSON. Jika file belum ada, data kosong dikembalikan;
+// file akan dibuat saat pertama kali menyimpan.
+func (s *JSONStore) Load(ctx context.Context) (*Data, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := newData()
+	file, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return data, nil
+		}
+		return nil, err
+	}
+
+	if err := sonic.Unmarshal(file, data); err != nil {
+		return nil, err
+	}
+	data.ensureMaps()
+	return data, nil
+}
+
+// Save menulis seluruh data ke file JSON secara atomik.
+func (s *JSONStore) Save(ctx context.Context, data *Data) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, err := sonic.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return storage.WriteFileAtomic(s.filePath, raw)
+}
@@ -0,0 +1,203 @@
+package permissions
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Satr10/wa-userbot/internal/storage"
+)
+
+// permissionMigrations adalah skema tabel izin. Jangan ubah entri lama, tambahkan versi baru di akhir.
+var permissionMigrations = []string{
+	`CREATE TABLE perm_allowed_groups (
+		group_id TEXT PRIMARY KEY
+	);
+	CREATE TABLE perm_allowed_users (
+		user_id TEXT PRIMARY KEY
+	);`,
+	`CREATE TABLE perm_roles (
+		user_id  TEXT NOT NULL,
+		group_id TEXT NOT NULL DEFAULT '',
+		role     TEXT NOT NULL,
+		PRIMARY KEY (user_id, group_id, role)
+	);
+	CREATE TABLE perm_grants (
+		command TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		allow   BOOLEAN NOT NULL,
+		PRIMARY KEY (command, subject)
+	);`,
+	`CREATE TABLE perm_afk_lists (
+		id   TEXT PRIMARY KEY,
+		list TEXT NOT NULL
+	);`,
+}
+
+// PostgresStore menyimpan data izin di tabel Postgres, satu baris per entri.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore membuat store Postgres dan menjalankan migrasi skema yang belum diterapkan.
+func NewPostgresStore(ctx context.Context, db *sql.DB) (*PostgresStore, error) {
+	if err := storage.Migrate(ctx, db, "permissions", permissionMigrations); err != nil {
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+// Load membaca seluruh tabel izin.
+func (s *PostgresStore) Load(ctx context.Context) (*Data, error) {
+	data := newData()
+
+	if err := s.queryEach(ctx, `SELECT group_id FROM perm_allowed_groups`, func(rows *sql.Rows) error {
+		var groupID string
+		if err := rows.Scan(&groupID); err != nil {
+			return err
+		}
+		data.AllowedGroups[groupID] = true
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.queryEach(ctx, `SELECT user_id FROM perm_allowed_users`, func(rows *sql.Rows) error {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return err
+		}
+		data.AllowedUsers[userID] = true
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.queryEach(ctx, `SELECT user_id, group_id, role FROM perm_roles ORDER BY user_id, group_id, role`, func(rows *sql.Rows) error {
+		var userID, groupID, role string
+		if err := rows.Scan(&userID, &groupID, &role); err != nil {
+			return err
+		}
+		if groupID == "" {
+			data.Roles[userID] = append(data.Roles[userID], role)
+			return nil
+		}
+		if data.GroupRoles[groupID] == nil {
+			data.GroupRoles[groupID] = make(map[string][]string)
+		}
+		data.GroupRoles[groupID][userID] = append(data.GroupRoles[groupID][userID], role)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.queryEach(ctx, `SELECT command, subject, allow FROM perm_grants`, func(rows *sql.Rows) error {
+		var command, subject string
+		var allow bool
+		if err := rows.Scan(&command, &subject, &allow); err != nil {
+			return err
+		}
+		if data.Grants[command] == nil {
+			data.Grants[command] = make(map[string]bool)
+		}
+		data.Grants[command][subject] = allow
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.queryEach(ctx, `SELECT id, list FROM perm_afk_lists`, func(rows *sql.Rows) error {
+		var id, list string
+		if err := rows.Scan(&id, &list); err != nil {
+			return err
+		}
+		data.AFKLists[id] = list
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// queryEach menjalankan query dan memanggil fn untuk setiap baris.
+func (s *PostgresStore) queryEach(ctx context.Context, query string, fn func(*sql.Rows) error) error {
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("gagal membaca data izin: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Save menulis ulang seluruh tabel izin dalam satu transaksi.
+func (s *PostgresStore) Save(ctx context.Context, data *Data) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"perm_allowed_groups", "perm_allowed_users", "perm_roles", "perm_grants", "perm_afk_lists"} {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table); err != nil {
+			return fmt.Errorf("gagal mengosongkan %s: %w", table, err)
+		}
+	}
+
+	for groupID, allowed := range data.AllowedGroups {
+		if !allowed {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO perm_allowed_groups (group_id) VALUES ($1)`, groupID); err != nil {
+			return fmt.Errorf("gagal menyimpan grup %s: %w", groupID, err)
+		}
+	}
+	for userID, allowed := range data.AllowedUsers {
+		if !allowed {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO perm_allowed_users (user_id) VALUES ($1)`, userID); err != nil {
+			return fmt.Errorf("gagal menyimpan pengguna %s: %w", userID, err)
+		}
+	}
+
+	insertRole := `INSERT INTO perm_roles (user_id, group_id, role) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+	for userID, roles := range data.Roles {
+		for _, role := range roles {
+			if _, err := tx.ExecContext(ctx, insertRole, userID, "", role); err != nil {
+				return fmt.Errorf("gagal menyimpan role %s untuk %s: %w", role, userID, err)
+			}
+		}
+	}
+	for groupID, users := range data.GroupRoles {
+		for userID, roles := range users {
+			for _, role := range roles {
+				if _, err := tx.ExecContext(ctx, insertRole, userID, groupID, role); err != nil {
+					return fmt.Errorf("gagal menyimpan role %s untuk %s di %s: %w", role, userID, groupID, err)
+				}
+			}
+		}
+	}
+
+	for command, grants := range data.Grants {
+		for subject, allow := range grants {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO perm_grants (command, subject, allow) VALUES ($1, $2, $3)`, command, subject, allow); err != nil {
+				return fmt.Errorf("gagal menyimpan grant %s untuk %s: %w", command, subject, err)
+			}
+		}
+	}
+
+	for id, list := range data.AFKLists {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO perm_afk_lists (id, list) VALUES ($1, $2)`, id, list); err != nil {
+			return fmt.Errorf("gagal menyimpan daftar AFK untuk %s: %w", id, err)
+		}
+	}
+
+	return tx.Commit()
+}
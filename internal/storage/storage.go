@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Satr10/wa-userbot/internal/config"
+	_ "github.com/lib/pq"
+)
+
+// Jenis backend penyimpanan yang didukung.
+const (
+	KindPostgres = "postgres"
+	KindJSON     = "json"
+)
+
+// Backend menentukan di mana data bot (izin, state, cache) disimpan.
+// Untuk KindPostgres, DB terisi; untuk KindJSON, data ditulis sebagai file di Dir.
+type Backend struct {
+	Kind string
+	DB   *sql.DB
+	Dir  string
+}
+
+// Open membuka backend sesuai config.StorageBackend.
+func Open(ctx context.Context, cfg config.Config) (*Backend, error) {
+	switch cfg.StorageBackend {
+	case KindPostgres:
+		db, err := sql.Open("postgres", cfg.PostgressURI)
+		if err != nil {
+			return nil, fmt.Errorf("gagal membuka koneksi postgres: %w", err)
+		}
+		if err := db.PingContext(ctx); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("gagal terhubung ke postgres: %w", err)
+		}
+		return &Backend{Kind: KindPostgres, DB: db}, nil
+	case KindJSON:
+		if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
+			return nil, fmt.Errorf("gagal membuat direktori data %s: %w", cfg.DataDir, err)
+		}
+		return &Backend{Kind: KindJSON, Dir: cfg.DataDir}, nil
+	default:
+		return nil, fmt.Errorf("storage backend %q tidak dikenal, gunakan %q atau %q", cfg.StorageBackend, KindPostgres, KindJSON)
+	}
+}
+
+// Path mengembalikan lokasi file JSON di direktori data.
+func (b *Backend) Path(name string) string {
+	return filepath.Join(b.Dir, name)
+}
+
+// Close menutup koneksi database jika ada.
+func (b *Backend) Close() error {
+	if b.DB != nil {
+		return b.DB.Close()
+	}
+	return nil
+}
+
+// Migrate menjalankan migrasi skema yang belum diterapkan untuk satu komponen.
+// Versi disimpan per komponen di tabel schema_versions; migrations[i] adalah versi i+1
+// dan tidak boleh diubah setelah dirilis, hanya ditambah di akhir.
+func Migrate(ctx context.Context, db *sql.DB, component string, migrations []string) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_versions (
+		component TEXT PRIMARY KEY,
+		version   INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("gagal membuat tabel schema_versions: %w", err)
+	}
+
+	var version int
+	err = db.QueryRowContext(ctx, `SELECT version FROM schema_versions WHERE component = $1`, component).Scan(&version)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("gagal membaca versi skema %s: %w", component, err)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migrasi %s versi %d gagal: %w", component, i+1, err)
+		}
+		_, err = tx.ExecContext(ctx, `INSERT INTO schema_versions (component, version) VALUES ($1, $2)
+			ON CONFLICT (component) DO UPDATE SET version = EXCLUDED.version`, component, i+1)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("gagal menyimpan versi skema %s: %w", component, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFileAtomic menulis file lewat file sementara lalu rename,
+// sehingga file tidak pernah setengah tertulis jika proses mati di tengah jalan.
+func WriteFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,218 @@
+package threatintel
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Satr10/wa-userbot/internal/urlcanon"
+)
+
+// maxFeedSize membatasi ukuran feed yang diunduh agar sumber yang salah konfigurasi
+// tidak menghabiskan memori.
+const maxFeedSize = 64 << 20
+
+// Categories adalah kategori verdict yang boleh dipakai feed, sama dengan kategori Gemini.
+var Categories = []string{"PHISHING", "MALWARE", "SUSPICIOUS"}
+
+// Feed adalah satu sumber daftar indikator. Source bisa berupa URL http(s) atau path file.
+type Feed struct {
+	Name     string
+	Category string
+	Source   string
+}
+
+// IsRemote memeriksa apakah feed diunduh lewat HTTP.
+func (f Feed) IsRemote() bool {
+	return strings.HasPrefix(f.Source, "http://") || strings.HasPrefix(f.Source, "https://")
+}
+
+// ParseFeeds membaca spesifikasi "KATEGORI=sumber,..." seperti pada THREAT_FEEDS,
+// misal "PHISHING=https://openphish.com/feed.txt,MALWARE=/data/urlhaus.csv".
+// Nama feed diambil dari host URL atau nama file sumber.
+func ParseFeeds(spec string) ([]Feed, error) {
+	var feeds []Feed
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		category, source, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(source) == "" {
+			return nil, fmt.Errorf("feed %q harus berformat KATEGORI=sumber", part)
+		}
+		category = strings.ToUpper(strings.TrimSpace(category))
+		if !isCategory(category) {
+			return nil, fmt.Errorf("kategori feed %q tidak dikenal, gunakan %s", category, strings.Join(Categories, ", "))
+		}
+
+		feed := Feed{Category: category, Source: strings.TrimSpace(source)}
+		if feed.IsRemote() {
+			u, err := url.Parse(feed.Source)
+			if err != nil || u.Host == "" {
+				return nil, fmt.Errorf("URL feed %q tidak valid", feed.Source)
+			}
+			feed.Name = u.Hostname()
+		} else {
+			feed.Name = filepath.Base(feed.Source)
+		}
+		feeds = append(feeds, feed)
+	}
+	return feeds, nil
+}
+
+func isCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// index adalah isi satu feed yang siap dicocokkan. urls dikunci dengan urlKey,
+// domains dengan host kanonik.
+type index struct {
+	urls    map[string]struct{}
+	domains map[string]struct{}
+}
+
+// Size mengembalikan jumlah indikator dalam index.
+func (ix *index) Size() int {
+	return len(ix.urls) + len(ix.domains)
+}
+
+// open membuka sumber feed untuk dibaca.
+func open(ctx context.Context, client *http.Client, feed Feed) (io.ReadCloser, error) {
+	if !feed.IsRemote() {
+		return os.Open(feed.Source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feed.Source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("status HTTP %d", resp.StatusCode)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(resp.Body, maxFeedSize), resp.Body}, nil
+}
+
+// parse membaca feed baris per baris. Format dikenali per baris sehingga dump campuran tetap terbaca:
+//   - hostfile: "0.0.0.0 evil.example" (semua host setelah alamat IP);
+//   - CSV seperti URLhaus/PhishTank: kolom pertama yang berisi "://" dipakai sebagai URL,
+//     jika tidak ada, kolom pertama yang berbentuk domain;
+//   - satu URL per baris seperti OpenPhish;
+//   - satu domain per baris.
+//
+// Baris kosong dan komentar ("#", "!", ";") dilewati, begitu juga baris yang tidak bisa dibaca.
+func parse(r io.Reader) (*index, error) {
+	ix := &index{urls: make(map[string]struct{}), domains: make(map[string]struct{})}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.IndexByte("#!;", line[0]) >= 0 {
+			continue
+		}
+
+		switch fields := strings.Fields(stripComment(line)); {
+		case len(fields) >= 2 && net.ParseIP(fields[0]) != nil:
+			for _, host := range fields[1:] {
+				ix.addDomain(host)
+			}
+		case strings.ContainsAny(line, ",\""):
+			ix.addCSV(line)
+		case len(fields) == 1 && (strings.Contains(line, "://") || strings.Contains(line, "/")):
+			ix.addURL(line)
+		case len(fields) == 1:
+			ix.addDomain(line)
+		}
+	}
+	return ix, scanner.Err()
+}
+
+// stripComment membuang komentar di akhir baris hostfile, misal "0.0.0.0 evil.example # iklan".
+func stripComment(line string) string {
+	if i := strings.Index(line, " #"); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+func (ix *index) addCSV(line string) {
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	fields, err := reader.Read()
+	if err != nil {
+		return
+	}
+	for _, field := range fields {
+		if strings.Contains(field, "://") {
+			ix.addURL(field)
+			return
+		}
+	}
+	for _, field := range fields {
+		if looksLikeDomain(field) {
+			ix.addDomain(field)
+			return
+		}
+	}
+}
+
+func (ix *index) addURL(raw string) {
+	canonical, err := urlcanon.Canonicalize(raw)
+	if err != nil {
+		return
+	}
+	if key := urlKey(canonical); key != "" {
+		ix.urls[key] = struct{}{}
+	}
+}
+
+func (ix *index) addDomain(raw string) {
+	if !looksLikeDomain(raw) {
+		return
+	}
+	host, err := urlcanon.CanonicalHost(raw)
+	if err != nil {
+		return
+	}
+	ix.domains[host] = struct{}{}
+}
+
+// looksLikeDomain menyaring kolom yang jelas bukan domain, termasuk nama lokal seperti "localhost"
+// yang sering ada di hostfile.
+func looksLikeDomain(s string) bool {
+	return strings.Contains(s, ".") && !strings.ContainsAny(s, "/:@ \t") && net.ParseIP(s) == nil
+}
+
+// urlKey adalah kunci URL dalam index: URL kanonik tanpa skema, sehingga feed yang mencatat
+// "http://" tetap cocok dengan link yang dikirim tanpa skema (dikanonikkan ke https).
+func urlKey(canonicalURL string) string {
+	u, err := url.Parse(canonicalURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	u.Scheme = ""
+	return strings.TrimPrefix(u.String(), "//")
+}
@@ -0,0 +1,193 @@
+// Package threatintel memuat daftar domain dan URL phishing/malware dari feed lokal atau
+// remote, lalu mencocokkannya secara offline sebelum URL dikirim ke Gemini.
+package threatintel
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Satr10/wa-userbot/internal/logger"
+)
+
+// fetchTimeout membatasi lama satu unduhan feed remote.
+const fetchTimeout = 2 * time.Minute
+
+// Match adalah indikator feed yang cocok dengan sebuah URL.
+type Match struct {
+	Feed     string `json:"feed"`
+	Category string `json:"category"`
+	// Kind bernilai "url" jika URL persis ada di feed, atau "domain" jika host (atau induknya) yang tercatat.
+	Kind      string `json:"kind"`
+	Indicator string `json:"indicator"`
+}
+
+// Status adalah keadaan satu feed untuk ditampilkan ke owner.
+type Status struct {
+	Feed
+	Indicators int
+	LoadedAt   time.Time
+	LastError  error
+}
+
+// feedState menyimpan index terakhir yang berhasil dimuat dari satu feed.
+type feedState struct {
+	Feed
+	index    *index
+	loadedAt time.Time
+	lastErr  error
+}
+
+// Manager memegang semua feed dan memuat ulangnya secara berkala.
+type Manager struct {
+	mu      sync.RWMutex
+	feeds   []*feedState
+	refresh time.Duration
+	client  *http.Client
+	log     *slog.Logger
+}
+
+// NewManager membuat manager dari spesifikasi THREAT_FEEDS dan interval refresh seperti "6h".
+// Feed belum dimuat sampai Start atau Refresh dipanggil.
+func NewManager(feedSpec, refresh string) (*Manager, error) {
+	feeds, err := ParseFeeds(feedSpec)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := time.ParseDuration(refresh)
+	if err != nil || interval < 0 {
+		return nil, fmt.Errorf("interval refresh feed %q tidak valid", refresh)
+	}
+
+	m := &Manager{
+		refresh: interval,
+		client:  &http.Client{Timeout: fetchTimeout},
+		log:     logger.Get(),
+	}
+	for _, feed := range feeds {
+		m.feeds = append(m.feeds, &feedState{Feed: feed})
+	}
+	return m, nil
+}
+
+// Start memuat semua feed di background lalu memuat ulang setiap interval refresh
+// sampai ctx selesai. Interval 0 berarti feed hanya dimuat sekali.
+func (m *Manager) Start(ctx context.Context) {
+	if len(m.feeds) == 0 {
+		return
+	}
+	go func() {
+		m.Refresh(ctx)
+		if m.refresh == 0 {
+			return
+		}
+		ticker := time.NewTicker(m.refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.Refresh(ctx)
+			}
+		}
+	}()
+}
+
+// Refresh memuat ulang semua feed dan mengembalikan jumlah feed yang gagal.
+// Feed yang gagal tetap memakai data dari pemuatan terakhir yang berhasil.
+func (m *Manager) Refresh(ctx context.Context) int {
+	failed := 0
+	for _, state := range m.feeds {
+		ix, err := m.load(ctx, state.Feed)
+
+		m.mu.Lock()
+		state.lastErr = err
+		if err == nil {
+			state.index = ix
+			state.loadedAt = time.Now()
+		}
+		m.mu.Unlock()
+
+		if err != nil {
+			failed++
+			m.log.Warn("failed to load threat feed", "feed", state.Name, "source", state.Source, "error", err)
+			continue
+		}
+		m.log.Info("threat feed loaded", "feed", state.Name, "indicators", ix.Size())
+	}
+	return failed
+}
+
+func (m *Manager) load(ctx context.Context, feed Feed) (*index, error) {
+	r, err := open(ctx, m.client, feed)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return parse(r)
+}
+
+// Match mencari URL kanonik di semua feed. URL persis didahulukan, lalu root situs
+// ("host/"), lalu host dan domain induknya sampai satu level di bawah TLD.
+func (m *Manager) Match(canonicalURL string) (Match, bool) {
+	key := urlKey(canonicalURL)
+	u, err := url.Parse(canonicalURL)
+	if key == "" || err != nil {
+		return Match{}, false
+	}
+	host := u.Hostname()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, candidate := range []string{key, u.Host + "/"} {
+		for _, state := range m.feeds {
+			if state.index == nil {
+				continue
+			}
+			if _, ok := state.index.urls[candidate]; ok {
+				return Match{Feed: state.Name, Category: state.Category, Kind: "url", Indicator: candidate}, true
+			}
+		}
+	}
+	for domain := host; strings.Contains(domain, "."); domain = domain[strings.IndexByte(domain, '.')+1:] {
+		for _, state := range m.feeds {
+			if state.index == nil {
+				continue
+			}
+			if _, ok := state.index.domains[domain]; ok {
+				return Match{Feed: state.Name, Category: state.Category, Kind: "domain", Indicator: domain}, true
+			}
+		}
+	}
+	return Match{}, false
+}
+
+// Statuses mengembalikan keadaan semua feed sesuai urutan konfigurasi.
+func (m *Manager) Statuses() []Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	statuses := make([]Status, 0, len(m.feeds))
+	for _, state := range m.feeds {
+		status := Status{Feed: state.Feed, LoadedAt: state.loadedAt, LastError: state.lastErr}
+		if state.index != nil {
+			status.Indicators = state.index.Size()
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// Indicators mengembalikan jumlah seluruh indikator yang sedang dimuat.
+func (m *Manager) Indicators() int {
+	total := 0
+	for _, status := range m.Statuses() {
+		total += status.Indicators
+	}
+	return total
+}
@@ -0,0 +1,109 @@
+// Package urlcache menyimpan hasil akhir pemindaian URL agar link yang sama
+// tidak diinvestigasi ulang oleh Gemini sampai masa berlakunya habis.
+package urlcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Satr10/wa-userbot/internal/ai"
+	"github.com/Satr10/wa-userbot/internal/durations"
+)
+
+// Entry adalah satu hasil pemindaian yang tersimpan.
+type Entry struct {
+	URL       string           `json:"url"`
+	Result    ai.URLScanResult `json:"result"`
+	CachedAt  time.Time        `json:"cachedAt"`
+	ExpiresAt time.Time        `json:"expiresAt"`
+}
+
+// Cache membungkus Store dengan aturan TTL per kategori.
+type Cache struct {
+	store Store
+	ttl   map[string]time.Duration
+}
+
+// New membuat cache dengan TTL dari spesifikasi seperti config.DefaultURLCacheTTL.
+// Kategori yang tidak disebut memakai entri "*"; tanpa "*", kategori itu tidak di-cache.
+func New(store Store, ttlSpec string) (*Cache, error) {
+	ttl, err := ParseTTL(ttlSpec)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{store: store, ttl: ttl}, nil
+}
+
+// Get mengembalikan hasil tersimpan untuk URL, atau nil jika tidak ada atau sudah kedaluwarsa.
+// URL harus sudah dalam bentuk kanonik (lihat urlcanon.Canonicalize).
+func (c *Cache) Get(ctx context.Context, canonicalURL string) (*Entry, error) {
+	entry, err := c.store.Get(ctx, Key(canonicalURL))
+	if err != nil || entry == nil {
+		return nil, err
+	}
+	if time.Now().After(entry.ExpiresAt) {
+		return nil, nil
+	}
+	return entry, nil
+}
+
+// Put menyimpan hasil pemindaian yang sudah selesai. Hasil berstatus selain COMPLETED dan
+// verdict heuristik tidak disimpan agar kegagalan sementara tidak ikut ter-cache dan link
+// diperiksa ulang oleh Gemini setelah layanan pulih.
+func (c *Cache) Put(ctx context.Context, canonicalURL string, result *ai.URLScanResult) error {
+	if result == nil || result.Heuristic || !strings.EqualFold(result.Status, "COMPLETED") {
+		return nil
+	}
+	ttl := c.TTL(result.FinalVerdict.Category)
+	if ttl <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	return c.store.Put(ctx, Key(canonicalURL), &Entry{
+		URL:       canonicalURL,
+		Result:    *result,
+		CachedAt:  now,
+		ExpiresAt: now.Add(ttl),
+	})
+}
+
+// TTL mengembalikan masa berlaku untuk kategori verdict.
+func (c *Cache) TTL(category string) time.Duration {
+	if ttl, ok := c.ttl[strings.ToUpper(category)]; ok {
+		return ttl
+	}
+	return c.ttl["*"]
+}
+
+// Key adalah kunci cache untuk URL kanonik: SHA-256 dari URL tersebut.
+func Key(canonicalURL string) string {
+	hash := sha256.Sum256([]byte(canonicalURL))
+	return hex.EncodeToString(hash[:])
+}
+
+// ParseTTL membaca spesifikasi "KATEGORI=durasi,..." dengan durasi format time.ParseDuration
+// ditambah akhiran "d" untuk hari dan "w" untuk minggu.
+func ParseTTL(spec string) (map[string]time.Duration, error) {
+	ttl := make(map[string]time.Duration)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		category, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("TTL %q harus berformat KATEGORI=durasi", part)
+		}
+		d, err := durations.Parse(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("TTL %s: %w", category, err)
+		}
+		ttl[strings.ToUpper(strings.TrimSpace(category))] = d
+	}
+	return ttl, nil
+}
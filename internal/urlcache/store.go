@@ -0,0 +1,165 @@
+package urlcache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Satr10/wa-userbot/internal/storage"
+	"github.com/bytedance/sonic"
+)
+
+// Store adalah tempat penyimpanan entri cache, dikunci dengan Key(url).
+// Get mengembalikan nil tanpa error jika kunci tidak ada.
+type Store interface {
+	Get(ctx context.Context, key string) (*Entry, error)
+	Put(ctx context.Context, key string, entry *Entry) error
+}
+
+// NewStore memilih implementasi Store sesuai jenis backend.
+func NewStore(ctx context.Context, backend *storage.Backend) (Store, error) {
+	switch backend.Kind {
+	case storage.KindPostgres:
+		return NewPostgresStore(ctx, backend.DB)
+	case storage.KindJSON:
+		return NewJSONStore(backend.Path("url_cache.json"))
+	default:
+		return nil, fmt.Errorf("storage backend %q tidak didukung untuk cache URL", backend.Kind)
+	}
+}
+
+// JSONStore menyimpan seluruh cache di memori dan menuliskannya ke satu file JSON.
+// Perubahan baru dipasang di memori setelah file berhasil ditulis, dan entri kedaluwarsa
+// hanya dibuang saat memang ada yang kedaluwarsa.
+type JSONStore struct {
+	filePath string
+	mu       sync.Mutex
+	entries  map[string]*Entry
+}
+
+// NewJSONStore memuat cache dari file JSON di path tersebut, jika ada.
+func NewJSONStore(path string) (*JSONStore, error) {
+	s := &JSONStore{filePath: path, entries: make(map[string]*Entry)}
+	file, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := sonic.Unmarshal(file, &s.entries); err != nil {
+		return nil, fmt.Errorf("gagal membaca cache URL %s: %w", path, err)
+	}
+	return s, nil
+}
+
+func (s *JSONStore) Get(ctx context.Context, key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	e := *entry
+	return &e, nil
+}
+
+func (s *JSONStore) Put(ctx context.Context, key string, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	next := maps.Clone(s.entries)
+	if next == nil {
+		next = make(map[string]*Entry)
+	}
+	if hasExpired(next, now) {
+		maps.DeleteFunc(next, func(_ string, e *Entry) bool {
+			return now.After(e.ExpiresAt)
+		})
+	}
+	next[key] = entry
+
+	raw, err := sonic.MarshalIndent(next, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := storage.WriteFileAtomic(s.filePath, raw); err != nil {
+		return err
+	}
+	s.entries = next
+	return nil
+}
+
+// hasExpired melaporkan apakah ada entri yang sudah kedaluwarsa pada waktu now.
+func hasExpired(entries map[string]*Entry, now time.Time) bool {
+	for _, e := range entries {
+		if now.After(e.ExpiresAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// urlCacheMigrations adalah skema tabel cache URL. Jangan ubah entri lama, tambahkan versi baru di akhir.
+var urlCacheMigrations = []string{
+	`CREATE TABLE url_verdicts (
+		key        TEXT PRIMARY KEY,
+		url        TEXT NOT NULL,
+		category   TEXT NOT NULL,
+		result     JSONB NOT NULL,
+		cached_at  TIMESTAMPTZ NOT NULL,
+		expires_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX url_verdicts_expires_at ON url_verdicts (expires_at);`,
+}
+
+// PostgresStore menyimpan cache di tabel url_verdicts, satu baris per URL.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore membuat store Postgres dan menjalankan migrasi skema yang belum diterapkan.
+func NewPostgresStore(ctx context.Context, db *sql.DB) (*PostgresStore, error) {
+	if err := storage.Migrate(ctx, db, "urlcache", urlCacheMigrations); err != nil {
+		return nil, err
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, key string) (*Entry, error) {
+	var entry Entry
+	var raw []byte
+	err := s.db.QueryRowContext(ctx, `SELECT url, result, cached_at, expires_at FROM url_verdicts WHERE key = $1`, key).
+		Scan(&entry.URL, &raw, &entry.CachedAt, &entry.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca cache URL: %w", err)
+	}
+	if err := sonic.Unmarshal(raw, &entry.Result); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *PostgresStore) Put(ctx context.Context, key string, entry *Entry) error {
+	raw, err := sonic.Marshal(entry.Result)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM url_verdicts WHERE expires_at < now()`); err != nil {
+		return fmt.Errorf("gagal membersihkan cache URL: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO url_verdicts (key, url, category, result, cached_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (key) DO UPDATE SET url = EXCLUDED.url, category = EXCLUDED.category,
+			result = EXCLUDED.result, cached_at = EXCLUDED.cached_at, expires_at = EXCLUDED.expires_at`,
+		key, entry.URL, entry.Result.FinalVerdict.Category, raw, entry.CachedAt, entry.ExpiresAt)
+	return err
+}
@@ -0,0 +1,177 @@
+// Package urlcanon mengubah URL yang ditemukan di pesan menjadi bentuk kanonik,
+// sehingga variasi penulisan link yang sama di-hash, di-cache dan dipindai sebagai satu URL.
+package urlcanon
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// trackingParams adalah parameter query yang hanya dipakai untuk pelacakan kampanye
+// dan tidak mengubah isi halaman.
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "gbraid": true, "wbraid": true,
+	"msclkid": true, "yclid": true, "twclid": true, "ttclid": true, "igshid": true,
+	"mc_cid": true, "mc_eid": true, "_ga": true, "_gl": true, "_hsenc": true, "_hsmi": true,
+	"mkt_tok": true, "vero_id": true, "rb_clickid": true, "s_cid": true,
+}
+
+// trackingPrefixes adalah awalan parameter pelacakan, misal utm_source dan utm_medium.
+var trackingPrefixes = []string{"utm_", "pk_", "mtm_"}
+
+// Canonicalize mengembalikan bentuk kanonik URL: tanda baca di akhir dibuang, skema default
+// https, skema dan host huruf kecil, host internasional diubah ke punycode, port default,
+// fragment dan parameter pelacakan dibuang. URL dengan skema selain http/https hanya
+// dirapikan skemanya. Error dikembalikan jika URL http/https tidak punya host yang valid.
+func Canonicalize(raw string) (string, error) {
+	raw = TrimPunctuation(strings.TrimSpace(raw))
+	if raw == "" {
+		return "", fmt.Errorf("URL kosong")
+	}
+
+	if scheme, rest, ok := strings.Cut(raw, ":"); ok && !strings.HasPrefix(rest, "//") && isScheme(scheme) && !looksLikeHostPort(rest) {
+		// Skema tanpa authority seperti mailto: atau tel: tidak punya host untuk dirapikan.
+		return strings.ToLower(scheme) + ":" + rest, nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("URL %q tidak valid: %w", raw, err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return u.String(), nil
+	}
+
+	host, err := CanonicalHost(u.Hostname())
+	if err != nil {
+		return "", err
+	}
+	port := u.Port()
+	if (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		port = ""
+	}
+	u.Host = host
+	if strings.Contains(host, ":") {
+		u.Host = "[" + host + "]"
+	}
+	if port != "" {
+		u.Host += ":" + port
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.RawQuery = stripTracking(u.RawQuery)
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String(), nil
+}
+
+// CanonicalHost mengubah host ke huruf kecil tanpa titik di akhir, dan label internasional
+// ke bentuk punycode (xn--). Host yang ditolak profil Lookup (misal campuran aksara yang
+// mencurigakan) tetap dikonversi apa adanya agar bisa dipindai.
+func CanonicalHost(host string) (string, error) {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return "", fmt.Errorf("URL tidak memiliki host")
+	}
+	if strings.Contains(host, ":") {
+		// Alamat IPv6 tidak perlu IDNA.
+		return host, nil
+	}
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		if ascii, err = idna.Punycode.ToASCII(host); err != nil {
+			return "", fmt.Errorf("host %q tidak valid: %w", host, err)
+		}
+	}
+	return ascii, nil
+}
+
+// stripTracking membuang parameter pelacakan dari query tanpa mengubah urutan
+// maupun encoding parameter lainnya.
+func stripTracking(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	var kept []string
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(pair, "=")
+		if decoded, err := url.QueryUnescape(name); err == nil {
+			name = decoded
+		}
+		if isTrackingParam(strings.ToLower(name)) {
+			continue
+		}
+		kept = append(kept, pair)
+	}
+	return strings.Join(kept, "&")
+}
+
+func isTrackingParam(name string) bool {
+	if trackingParams[name] {
+		return true
+	}
+	for _, prefix := range trackingPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TrimPunctuation membuang tanda baca yang ikut tertangkap di akhir link, misal "example.com)."
+// Kurung tutup hanya dibuang jika tidak punya pasangan di dalam URL, sehingga link seperti
+// "https://en.wikipedia.org/wiki/Go_(programming_language)" tetap utuh.
+func TrimPunctuation(raw string) string {
+	pairs := map[byte]byte{')': '(', ']': '[', '}': '{', '>': '<'}
+	for raw != "" {
+		last := raw[len(raw)-1]
+		if strings.IndexByte(`.,;:!?'"*_~`, last) >= 0 {
+			raw = raw[:len(raw)-1]
+			continue
+		}
+		if open, ok := pairs[last]; ok && strings.Count(raw, string(open)) < strings.Count(raw, string(last)) {
+			raw = raw[:len(raw)-1]
+			continue
+		}
+		break
+	}
+	return raw
+}
+
+// isScheme memeriksa apakah s adalah nama skema URL yang valid menurut RFC 3986.
+func isScheme(s string) bool {
+	if s == "" || !isASCIILetter(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if !isASCIILetter(c) && !(c >= '0' && c <= '9') && c != '+' && c != '-' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// looksLikeHostPort membedakan "example.com:8080/path" (host dengan port) dari "mailto:..."
+// dengan memeriksa apakah teks setelah titik dua diawali angka port.
+func looksLikeHostPort(rest string) bool {
+	return rest != "" && rest[0] >= '0' && rest[0] <= '9'
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
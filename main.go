@@ -1,25 +1,99 @@
 package main
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
 
+	"github.com/Satr10/wa-userbot/internal/afk"
 	"github.com/Satr10/wa-userbot/internal/bot"
 	"github.com/Satr10/wa-userbot/internal/config"
+	"github.com/Satr10/wa-userbot/internal/domainlist"
 	"github.com/Satr10/wa-userbot/internal/permissions"
+	"github.com/Satr10/wa-userbot/internal/storage"
+	"github.com/Satr10/wa-userbot/internal/threatintel"
+	"github.com/Satr10/wa-userbot/internal/urlcache"
 	waLog "go.mau.fi/whatsmeow/util/log"
 )
 
 func main() {
 	logger := waLog.Stdout("Main", "Info", true)
 	cfg, _ := config.LoadConfig()
+	ctx := context.Background()
 
-	permManager, err := permissions.NewManager("/tmp/permissions.json")
+	backend, err := storage.Open(ctx, cfg)
+	if err != nil {
+		logger.Errorf("error opening storage backend err: %v", err)
+		return
+	}
+	defer backend.Close()
+
+	permStore, err := permissions.NewStore(ctx, backend)
+	if err != nil {
+		logger.Errorf("error creating permissions store err: %v", err)
+		return
+	}
+	permManager, err := permissions.NewManager(ctx, permStore)
 	if err != nil {
 		logger.Errorf("error creating new permissions manager err: %v", err)
+		return
+	}
+
+	afkDefaults, err := afk.DefaultSettings(cfg)
+	if err != nil {
+		logger.Errorf("error reading afk config err: %v", err)
+		return
+	}
+	afkStore, err := afk.NewStore(ctx, backend)
+	if err != nil {
+		logger.Errorf("error creating afk store err: %v", err)
+		return
 	}
-	botInstance, err := bot.NewBot(logger, cfg, permManager)
+	var afkCalendar *afk.Calendar
+	if paths := afk.CalendarPaths(cfg); len(paths) > 0 {
+		// Kalender yang gagal dimuat tidak menghentikan bot; file diperiksa ulang saat berubah.
+		afkCalendar, err = afk.NewCalendar(paths)
+		if err != nil {
+			logger.Warnf("error loading afk calendars err: %v", err)
+		}
+	}
+	afkManager, err := afk.NewManager(ctx, afkStore, afkDefaults, afkCalendar)
+	if err != nil {
+		logger.Errorf("error creating afk manager err: %v", err)
+		return
+	}
+
+	urlStore, err := urlcache.NewStore(ctx, backend)
+	if err != nil {
+		logger.Errorf("error creating url cache store err: %v", err)
+		return
+	}
+	urlCache, err := urlcache.New(urlStore, cfg.URLCacheTTL)
+	if err != nil {
+		logger.Errorf("error reading URL_CACHE_TTL err: %v", err)
+		return
+	}
+
+	domainStore, err := domainlist.NewStore(ctx, backend)
+	if err != nil {
+		logger.Errorf("error creating domain list store err: %v", err)
+		return
+	}
+	domains, err := domainlist.NewManager(ctx, domainStore)
+	if err != nil {
+		logger.Errorf("error creating domain list manager err: %v", err)
+		return
+	}
+
+	threatFeeds, err := threatintel.NewManager(cfg.ThreatFeeds, cfg.ThreatFeedRefresh)
+	if err != nil {
+		logger.Errorf("error reading THREAT_FEEDS err: %v", err)
+		return
+	}
+	threatFeeds.Start(ctx)
+
+	botInstance, err := bot.NewBot(logger, cfg, permManager, afkManager, urlCache, domains, threatFeeds)
 	if err != nil {
 		logger.Errorf("Error creating new bot instance, err: %v", err)
 		return
@@ -30,4 +104,7 @@ func main() {
 	<-c
 	logger.Infof("Shutting down...")
 	botInstance.Disconnect()
+	if err := afkManager.Flush(ctx); err != nil {
+		logger.Errorf("error saving afk state err: %v", err)
+	}
 }